@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// resolveAuthor returns the email whose branches --mine and --author list:
+// the one given by --author, else user.email with --mine, else "" for all.
+func resolveAuthor(opts options) (string, error) {
+	if opts.author != "" || !opts.mine {
+		return opts.author, nil
+	}
+	output, err := gitCommand("config", "user.email").Output()
+	email := strings.TrimSpace(string(output))
+	if err != nil || email == "" {
+		return "", errors.New("--mine needs git config user.email to be set; pass --author EMAIL otherwise")
+	}
+	return email, nil
+}
+
+// filterAuthor keeps the branches whose last commit was authored with the
+// email of --mine or --author. Emails are compared case-insensitively, as
+// mail servers treat them.
+func filterAuthor(ctx context.Context, branches []branch, opts options) ([]branch, error) {
+	if opts.author == "" {
+		return branches, nil
+	}
+
+	output, err := gitOutput(ctx, "for-each-ref", "--format=%(refname:short) %(authoremail)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+
+	// Branch names can't contain spaces
+	var mine []string
+	for _, line := range strings.Split(string(output), "\n") {
+		name, email, _ := strings.Cut(line, " ")
+		if strings.EqualFold(strings.Trim(email, "<>"), opts.author) {
+			mine = append(mine, name)
+		}
+	}
+	return slices.DeleteFunc(branches, func(b branch) bool {
+		return !slices.Contains(mine, b.name)
+	}), nil
+}
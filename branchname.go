@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkBranchName checks name with git check-ref-format --branch, so that an
+// invalid name is reported before anything is run, pointing at what git
+// objects to where it can.
+func checkBranchName(name string) error {
+	if gitCommand("check-ref-format", "--branch", name).Run() == nil {
+		return nil
+	}
+	if problem := branchNameProblem(name); problem != "" {
+		return fmt.Errorf("'%s' is not a valid branch name: it %s", name, problem)
+	}
+	return fmt.Errorf("'%s' is not a valid branch name", name)
+}
+
+// branchNameProblem describes the first part of name that breaks git's rules
+// for branch names, see git help check-ref-format, or returns "" if it finds
+// none.
+func branchNameProblem(name string) string {
+	switch {
+	case name == "":
+		return "is empty"
+	case name == "@":
+		return "is '@'"
+	case strings.HasPrefix(name, "-"):
+		return "starts with '-'"
+	case strings.HasPrefix(name, "/"):
+		return "starts with '/'"
+	case strings.HasSuffix(name, "/"):
+		return "ends with '/'"
+	case strings.HasSuffix(name, "."):
+		return "ends with '.'"
+	}
+
+	for i, r := range name {
+		switch {
+		case r == ' ':
+			return fmt.Sprintf("contains a space at position %d", i+1)
+		case r < ' ' || r == 0x7f:
+			return fmt.Sprintf("contains a control character at position %d", i+1)
+		case strings.ContainsRune(`~^:?*[\`, r):
+			return fmt.Sprintf("contains '%c' at position %d", r, i+1)
+		}
+	}
+	for _, seq := range []string{"..", "//", "@{"} {
+		if i := strings.Index(name, seq); i >= 0 {
+			return fmt.Sprintf("contains '%s' at position %d", seq, i+1)
+		}
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return fmt.Sprintf("has a part starting with '.': '%s'", part)
+		}
+		if strings.HasSuffix(part, ".lock") {
+			return fmt.Sprintf("has a part ending in '.lock': '%s'", part)
+		}
+	}
+	return ""
+}
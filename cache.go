@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultCacheTTL is how long branch lists are reused between invocations
+const defaultCacheTTL = 2 * time.Second
+
+// cacheTTL is how long cached git output stays valid. It is set in main and
+// left at 0, which disables the cache, everywhere else.
+var cacheTTL time.Duration
+
+// resolveCacheTTL picks the cache lifetime from --no-cache, GH_SW_CACHE_TTL
+// and then the default.
+func resolveCacheTTL(opts options) (time.Duration, error) {
+	if opts.noCache {
+		return 0, nil
+	}
+	value := os.Getenv("GH_SW_CACHE_TTL")
+	if value == "" {
+		return defaultCacheTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid GH_SW_CACHE_TTL: %s", value)
+	}
+	return ttl, nil
+}
+
+// cacheDir returns the directory holding the cache of the current
+// repository. It is under the user cache directory rather than the shared
+// temporary one, where another user could plant fake branch lists.
+func cacheDir() (string, error) {
+	repo, err := getRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-sw", hashKey(repo)), nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// gitOutput runs git with args and returns its stdout, reusing the output of
+// an identical command from less than cacheTTL ago. git's stderr is passed
+// through on failure.
+func gitOutput(ctx context.Context, args ...string) ([]byte, error) {
+	var path string
+	if cacheTTL > 0 {
+		if dir, err := cacheDir(); err == nil {
+			path = filepath.Join(dir, hashKey(strings.Join(args, "\x00")))
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < cacheTTL {
+				if output, err := os.ReadFile(path); err == nil {
+					return output, nil
+				}
+			}
+		}
+	}
+
+	var stderr bytes.Buffer
+	cmd := gitCommandContext(ctx, args...)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if jsonErrors {
+		// Reported as part of the JSON error instead
+		cmd.Stderr = &stderr
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, newGitError(args, stderr.String(), err)
+	}
+
+	// The cache is best effort, like the history
+	if path != "" && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+		_ = os.WriteFile(path, output, 0o600)
+	}
+	return output, nil
+}
+
+// invalidateCache drops the cache of the current repository once its
+// branches or HEAD have changed.
+func invalidateCache() {
+	if cacheTTL == 0 {
+		return
+	}
+	if dir, err := cacheDir(); err == nil {
+		_ = os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// checkoutFlags are the git checkout flags doing what those of git switch
+// do. Other flags are spelled the same by both.
+var checkoutFlags = map[string]string{
+	"-c":                "-b",
+	"-C":                "-B",
+	"--discard-changes": "--force",
+}
+
+// hasSwitch reports whether git is recent enough to have git switch, which
+// came with 2.23. It asks git once per run; a version that can't be read is
+// taken to be recent.
+var hasSwitch = sync.OnceValue(func() bool {
+	output, err := gitCommand("--version").Output()
+	if err != nil {
+		return true
+	}
+	return switchSupported(string(output))
+})
+
+// switchSupported reads the output of git --version, e.g. "git version
+// 2.39.2 (Apple Git-143)", and reports whether that git has git switch.
+func switchSupported(version string) bool {
+	fields := strings.Fields(version)
+	if len(fields) < 3 {
+		return true
+	}
+	parts := strings.SplitN(fields[2], ".", 3)
+	if len(parts) < 2 {
+		return true
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return true
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return true
+	}
+	return major > 2 || major == 2 && minor >= 23
+}
+
+var checkoutNotice sync.Once
+
+// switchCommand returns the arguments of git switch args, or of the git
+// checkout doing the same when git predates switch. The first time checkout
+// stands in, a note says so. The checkout ends in "--" so that a name that
+// is also a path is never taken for files to restore.
+func switchCommand(opts options, args ...string) []string {
+	if hasSwitch() {
+		return append([]string{"switch"}, args...)
+	}
+
+	checkoutNotice.Do(func() {
+		notice(opts, "git switch needs git 2.23 or later; using git checkout instead.")
+	})
+	command := []string{"checkout"}
+	for _, arg := range args {
+		if flag, ok := checkoutFlags[arg]; ok {
+			arg = flag
+		}
+		command = append(command, arg)
+	}
+	return append(command, "--")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// columnGap separates the columns of printColumns.
+const columnGap = 2
+
+// printColumns prints names down columns fitting the terminal, like ls, when
+// stdout is one, and one per line otherwise so that pipes and scripts get a
+// name per line as before.
+func printColumns(names []string) {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width = 0
+	}
+	for _, line := range columnLayout(names, width) {
+		fmt.Println(line)
+	}
+}
+
+// columnLayout lays names out in as many columns as fit in width, filling
+// each column top to bottom before the next. A width of 0 gives one column.
+func columnLayout(names []string, width int) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	colWidth := 0
+	for _, name := range names {
+		colWidth = max(colWidth, lipgloss.Width(name)+columnGap)
+	}
+	cols := max(1, (width+columnGap)/colWidth)
+	rows := (len(names) + cols - 1) / cols
+
+	lines := make([]string, rows)
+	for row := range rows {
+		var line strings.Builder
+		for col := range cols {
+			i := col*rows + row
+			if i >= len(names) {
+				break
+			}
+			// Pad the name before this one to the column width
+			if col > 0 {
+				line.WriteString(strings.Repeat(" ", colWidth-lipgloss.Width(names[i-rows])))
+			}
+			line.WriteString(names[i])
+		}
+		lines[row] = line.String()
+	}
+	return lines
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// config holds defaults for flags, read from gh-sw/config.json under the user
+// config directory. Flags and environment variables take precedence over it.
+type config struct {
+	Sort    string   `json:"sort"`
+	Exclude []string `json:"exclude"`
+	Theme   string   `json:"theme"`
+	Timeout string   `json:"timeout"`
+	Fetch   bool     `json:"fetch"`
+	Scope   string   `json:"scope"`
+	Git     string   `json:"git"`
+}
+
+// loadConfig reads the config file. A missing file yields the zero config;
+// unknown keys are rejected so that typos don't go unnoticed.
+func loadConfig() (config, error) {
+	var cfg config
+	path, err := configPath("config.json")
+	if err != nil {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// gitConfig overlays cfg with the sw.* keys of git config, which can be set
+// per repository: sw.sort, sw.exclude (one glob per value), sw.autofetch
+// and sw.scope. Keys that aren't set leave cfg as it is.
+func gitConfig(cfg config) (config, error) {
+	if values, err := gitConfigValues("sw.sort"); err != nil {
+		return cfg, err
+	} else if len(values) > 0 {
+		cfg.Sort = values[len(values)-1]
+	}
+
+	if values, err := gitConfigValues("sw.exclude"); err != nil {
+		return cfg, err
+	} else if len(values) > 0 {
+		cfg.Exclude = values
+	}
+
+	if values, err := gitConfigValues("sw.autofetch", "--type=bool"); err != nil {
+		return cfg, err
+	} else if len(values) > 0 {
+		cfg.Fetch = values[len(values)-1] == "true"
+	}
+
+	if values, err := gitConfigValues("sw.scope"); err != nil {
+		return cfg, err
+	} else if len(values) > 0 {
+		cfg.Scope = values[len(values)-1]
+	}
+	return cfg, nil
+}
+
+// gitConfigValues returns every value of key in git config, none if it
+// isn't set.
+func gitConfigValues(key string, flags ...string) ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := gitCommand(append(append([]string{"config"}, flags...), "--get-all", key)...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// git config exits with 1 when the key is missing
+		if exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git config %s: %s", key, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		// Most likely git is missing, which main reports
+		return nil, nil
+	}
+	return strings.Split(strings.TrimRight(string(output), "\n"), "\n"), nil
+}
+
+// applyConfig fills in the options left unset by flags and environment
+// variables from cfg, overlaid with the sw.* keys of git config. The theme
+// and the git binary are applied by main, which needs them first.
+func applyConfig(opts *options, cfg config) error {
+	cfg, err := gitConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	if opts.sort == "" && cfg.Sort != "" {
+		sort, err := parseSortKey(cfg.Sort)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		opts.sort = sort
+	}
+
+	if len(opts.exclude) == 0 {
+		for _, pattern := range cfg.Exclude {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("config: invalid exclude pattern %q: %w", pattern, err)
+			}
+		}
+		opts.exclude = cfg.Exclude
+	}
+
+	if opts.timeout == "" && os.Getenv("GH_SW_TIMEOUT") == "" {
+		opts.timeout = cfg.Timeout
+	}
+
+	if cfg.Fetch {
+		opts.fetch = true
+	}
+
+	if !opts.scopeSet {
+		opts.scope = cfg.Scope
+	}
+	opts.scope, err = resolveScope(opts.scope)
+	return err
+}
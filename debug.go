@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// debugMode is set by --debug or GH_SW_DEBUG to log every command gh-sw runs.
+var debugMode bool
+
+// command is an exec.Cmd that, in debug mode, logs its command line,
+// duration and exit code to stderr once it has run.
+type command struct {
+	*exec.Cmd
+}
+
+func (c *command) Run() error {
+	return c.log(c.Cmd.Run)
+}
+
+func (c *command) Output() ([]byte, error) {
+	var output []byte
+	err := c.log(func() error {
+		var err error
+		output, err = c.Cmd.Output()
+		return err
+	})
+	return output, err
+}
+
+func (c *command) CombinedOutput() ([]byte, error) {
+	var output []byte
+	err := c.log(func() error {
+		var err error
+		output, err = c.Cmd.CombinedOutput()
+		return err
+	})
+	return output, err
+}
+
+func (c *command) log(run func() error) error {
+	if !debugMode {
+		return run()
+	}
+
+	start := time.Now()
+	err := run()
+	status := "exit 0"
+	switch {
+	case c.ProcessState != nil:
+		status = fmt.Sprintf("exit %d", c.ProcessState.ExitCode())
+	case err != nil:
+		// It never started
+		status = err.Error()
+	}
+	fmt.Fprintf(os.Stderr, "[debug] %s (%s, %s)\n", strings.Join(c.Args, " "), time.Since(start).Round(time.Millisecond), status)
+	return err
+}
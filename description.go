@@ -0,0 +1,30 @@
+package main
+
+import "strings"
+
+// annotateDescriptions sets the description of branches that have one in
+// branch.<name>.description, as set by git branch --edit-description. All of
+// them are read at once rather than one git config per branch.
+func annotateDescriptions(branches []branch) {
+	// -z ends each entry with a NUL and puts a newline after the key, so that
+	// multi-line descriptions don't get in the way
+	output, err := gitCommand("config", "-z", "--get-regexp", `^branch\..*\.description$`).Output()
+	if err != nil {
+		// Also when no branch has a description
+		return
+	}
+
+	descriptions := map[string]string{}
+	for _, entry := range strings.Split(string(output), "\x00") {
+		key, value, _ := strings.Cut(entry, "\n")
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), ".description")
+		// Only the first line fits in the picker
+		value, _, _ = strings.Cut(strings.TrimSpace(value), "\n")
+		if value != "" {
+			descriptions[name] = value
+		}
+	}
+	for i := range branches {
+		branches[i].description = descriptions[branches[i].name]
+	}
+}
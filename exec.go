@@ -0,0 +1,43 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// switches reports whether opts check something out, as opposed to listing,
+// printing or managing branches, so that --exec has a switch to follow.
+// --onto and --reset-to only switch when given a branch.
+func switches(opts options) bool {
+	if (opts.onto != "" || opts.resetTo != "") && opts.branch == "" {
+		return false
+	}
+	return !opts.current && !opts.complete && !opts.json && !opts.print && !opts.dryRun &&
+		!opts.delete && !opts.rename && !opts.worktree && !opts.forget && opts.pin == "" && opts.unpin == ""
+}
+
+// runExec runs the --exec command through the user's shell in the
+// repository, with gh-sw's stdin, stdout and stderr. A failing command
+// comes back as an *exec.ExitError so that its exit code is passed on.
+func runExec(line string) error {
+	cmd := &command{exec.Command(cmp.Or(os.Getenv("SHELL"), "/bin/sh"), "-c", line)}
+	if runtime.GOOS == "windows" {
+		cmd = &command{exec.Command(cmp.Or(os.Getenv("ComSpec"), "cmd.exe"), "/C", line)}
+	}
+	cmd.Dir = repoDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	// A shell that can't be started is not to be mistaken for a missing git
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return fmt.Errorf("can't run --exec command: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/huh"
+)
+
+// favorite is a branch pinned to the top of the interactive list.
+type favorite struct {
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+}
+
+// loadFavorites reads the favorites file. A missing or unreadable file
+// yields no favorites.
+func loadFavorites() []favorite {
+	var favorites []favorite
+	if !loadJSON("favorites.json", &favorites) {
+		return nil
+	}
+	return favorites
+}
+
+func saveFavorites(favorites []favorite) error {
+	return saveJSON("favorites.json", favorites)
+}
+
+// pinBranch adds a local branch to the favorites of the current repository.
+func pinBranch(name string, opts options) error {
+	if !localBranchExists(name) {
+		return fmt.Errorf("branch '%s' does not exist", name)
+	}
+	repo, err := getRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	favorites := loadFavorites()
+	if !slices.Contains(favorites, favorite{Repo: repo, Branch: name}) {
+		favorites = append(favorites, favorite{Repo: repo, Branch: name})
+		if err := saveFavorites(favorites); err != nil {
+			return err
+		}
+	}
+	notice(opts, fmt.Sprintf("Pinned %s.", name))
+	return nil
+}
+
+// unpinBranch removes a branch from the favorites of the current repository.
+func unpinBranch(name string, opts options) error {
+	repo, err := getRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	favorites := loadFavorites()
+	kept := slices.DeleteFunc(slices.Clone(favorites), func(f favorite) bool {
+		return f.Repo == repo && f.Branch == name
+	})
+	if len(kept) == len(favorites) {
+		return fmt.Errorf("branch '%s' is not pinned", name)
+	}
+	if err := saveFavorites(kept); err != nil {
+		return err
+	}
+	notice(opts, fmt.Sprintf("Unpinned %s.", name))
+	return nil
+}
+
+// pinnedBranches returns the favorites among branches in the order they were
+// pinned, leaving out current. Favorites for branches that no longer exist
+// are pruned.
+func pinnedBranches(branches []branch, current string) []branch {
+	repo, err := getRepoRoot()
+	if err != nil {
+		return nil
+	}
+
+	favorites := loadFavorites()
+	pinned, pruned := storedBranches(&favorites, func(f favorite) (string, string) {
+		return f.Repo, f.Branch
+	}, repo, branches, current)
+
+	if pruned {
+		_ = saveFavorites(favorites)
+	}
+	return pinned
+}
+
+// pinnedOption is branchOption with a ★ marking the branch as pinned.
+func pinnedOption(b branch, cols columns) huh.Option[string] {
+	option := branchOption(b, columns{width: cols.width - 2, name: cols.name - 2})
+	option.Key = "★ " + option.Key
+	return option
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// matchStyle emphasizes the part of an option matching the filter.
+var matchStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+
+// filterMsg makes a form redraw its select after the filter changed it.
+type filterMsg struct{}
+
+// optionFilter filters the options of a branch select by what is typed after
+// "/", like huh's own filter, but matches their text without the styling and
+// highlights the match in each option, which huh can't do.
+type optionFilter struct {
+	field    *huh.Select[string]
+	title    string
+	options  []huh.Option[string]
+	selected *string
+	query    string
+	// typing is set while keys go to the query rather than the select
+	typing bool
+}
+
+// update handles msg if it concerns the filter and reports whether it did.
+// Everything else, such as moving the cursor, is left to the select.
+func (f *optionFilter) update(msg tea.KeyMsg) bool {
+	if !f.typing {
+		switch {
+		case msg.String() == "/":
+			f.typing = true
+		case msg.String() == "esc" && f.query != "":
+			f.query = ""
+		default:
+			return false
+		}
+		f.apply()
+		return true
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		f.query, f.typing = "", false
+	case tea.KeyEnter:
+		// Stop typing and let the select take the highlighted option
+		f.typing = false
+		f.apply()
+		return false
+	case tea.KeyBackspace:
+		runes := []rune(f.query)
+		f.query = string(runes[:max(len(runes)-1, 0)])
+	case tea.KeyCtrlU:
+		f.query = ""
+	case tea.KeyCtrlW:
+		f.query = strings.TrimRightFunc(f.query, unicode.IsSpace)
+		f.query = f.query[:strings.LastIndexFunc(f.query, unicode.IsSpace)+1]
+	case tea.KeyRunes, tea.KeySpace:
+		f.query += string(msg.Runes)
+	default:
+		return false
+	}
+	f.apply()
+	return true
+}
+
+// apply shows the query in the title and the options matching it in the
+// select, keeping the cursor on the same branch while it still matches.
+func (f *optionFilter) apply() {
+	title := f.title
+	if f.typing || f.query != "" {
+		title += " " + grayStyle.Render("/") + f.query
+		if f.typing {
+			title += "█"
+		}
+	}
+	f.field.Title(title)
+
+	options := f.options
+	if f.query != "" {
+		options = nil
+		for _, option := range f.options {
+			// Headers and notes only make sense among the full list
+			if option.Value == "" {
+				continue
+			}
+			if label, ok := highlight(option.Key, f.query); ok {
+				options = append(options, huh.NewOption(label, option.Value))
+			}
+		}
+		if len(options) == 0 {
+			options = []huh.Option[string]{huh.NewOption(grayStyle.Render("No matches"), "")}
+		}
+	}
+
+	if len(options) > 0 && !slices.ContainsFunc(options, func(o huh.Option[string]) bool { return o.Value == *f.selected }) {
+		*f.selected = options[0].Value
+	}
+	f.field.Options(options...)
+}
+
+// highlight finds query in label regardless of case and ignoring the
+// label's styling. It reports whether there is a match, and returns label
+// with every match emphasized by matchStyle while keeping its own styling
+// around them. Without styling, e.g. with NO_COLOR, label is left as is.
+func highlight(label, query string) (string, bool) {
+	var plain []rune
+	for _, part := range splitEscapes(label) {
+		if !strings.HasPrefix(part, "\x1b") {
+			plain = append(plain, []rune(part)...)
+		}
+	}
+	matched := matchRanges(plain, []rune(query))
+	if len(matched) == 0 {
+		return label, false
+	}
+
+	start, end, _ := strings.Cut(matchStyle.Render("\x00"), "\x00")
+	if start == "" {
+		return label, true
+	}
+
+	var b strings.Builder
+	// The styles in effect, which ending the emphasis would reset too
+	var active string
+	i := 0
+	for _, part := range splitEscapes(label) {
+		if strings.HasPrefix(part, "\x1b") {
+			b.WriteString(part)
+			if part == "\x1b[0m" || part == "\x1b[m" {
+				active = ""
+			} else {
+				active += part
+			}
+			continue
+		}
+		for _, r := range part {
+			if startsMatch(matched, i) {
+				b.WriteString(start)
+			}
+			b.WriteRune(r)
+			i++
+			if endsMatch(matched, i) {
+				b.WriteString(end + active)
+			}
+		}
+	}
+	return b.String(), true
+}
+
+// matchRanges returns the start and end of every match of query in text,
+// compared rune by rune regardless of case.
+func matchRanges(text, query []rune) [][2]int {
+	if len(query) == 0 {
+		return nil
+	}
+	var ranges [][2]int
+	for i := 0; i+len(query) <= len(text); {
+		if slices.EqualFunc(text[i:i+len(query)], query, func(a, b rune) bool {
+			return unicode.ToLower(a) == unicode.ToLower(b)
+		}) {
+			ranges = append(ranges, [2]int{i, i + len(query)})
+			i += len(query)
+			continue
+		}
+		i++
+	}
+	return ranges
+}
+
+func startsMatch(ranges [][2]int, i int) bool {
+	return slices.ContainsFunc(ranges, func(r [2]int) bool { return r[0] == i })
+}
+
+func endsMatch(ranges [][2]int, i int) bool {
+	return slices.ContainsFunc(ranges, func(r [2]int) bool { return r[1] == i })
+}
+
+// splitEscapes splits s into its ANSI escape sequences and the text between
+// them.
+func splitEscapes(s string) []string {
+	var parts []string
+	for s != "" {
+		i := strings.Index(s, "\x1b[")
+		if i == -1 {
+			parts = append(parts, s)
+			break
+		}
+		if i > 0 {
+			parts = append(parts, s[:i])
+		}
+		// A CSI sequence ends with a byte in the range @ to ~
+		j := i + 2
+		for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+			j++
+		}
+		j = min(j+1, len(s))
+		parts = append(parts, s[i:j])
+		s = s[j:]
+	}
+	return parts
+}
+
+// filteredForm runs a branch form whose select is filtered by an
+// optionFilter.
+type filteredForm struct {
+	form   *huh.Form
+	filter *optionFilter
+}
+
+// runBranchForm lets the user pick one of options with branchForm.
+func runBranchForm(title string, options []huh.Option[string], selected *string, opts options) error {
+	form, filter := branchForm(title, options, selected, opts)
+	form.SubmitCmd = tea.Quit
+	form.CancelCmd = tea.Interrupt
+
+	m := &filteredForm{form: form, filter: filter}
+	_, err := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithReportFocus()).Run()
+	if errors.Is(err, tea.ErrInterrupted) || form.State == huh.StateAborted {
+		return huh.ErrUserAborted
+	}
+	return err
+}
+
+func (m *filteredForm) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m *filteredForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.filter.update(key) {
+		msg = filterMsg{}
+	}
+	form, cmd := m.form.Update(msg)
+	m.form = form.(*huh.Form)
+	return m, cmd
+}
+
+func (m *filteredForm) View() string {
+	if m.form.State != huh.StateNormal {
+		return ""
+	}
+	return m.form.View()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// formatFields maps the placeholders of --format to git for-each-ref fields.
+var formatFields = map[string]string{
+	"name":     "%(refname:short)",
+	"upstream": "%(upstream:short)",
+	"track":    "%(upstream:track,nobracket)",
+	"subject":  "%(contents:subject)",
+	"date":     "%(committerdate:relative)",
+	"author":   "%(authorname)",
+	"hash":     "%(objectname:short)",
+}
+
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// parseFormat checks the placeholders of a --format template and turns it
+// into a git for-each-ref format.
+func parseFormat(template string) (string, error) {
+	var err error
+	// Anything but the placeholders is printed as is
+	format := placeholderPattern.ReplaceAllStringFunc(strings.ReplaceAll(template, "%", "%%"), func(placeholder string) string {
+		field, ok := formatFields[strings.Trim(placeholder, "{}")]
+		if !ok && err == nil {
+			names := make([]string, 0, len(formatFields))
+			for name := range formatFields {
+				names = append(names, "{"+name+"}")
+			}
+			slices.Sort(names)
+			err = fmt.Errorf("unknown placeholder in format: %s (expected %s)", placeholder, strings.Join(names, ", "))
+		}
+		return field
+	})
+	return format, err
+}
+
+// formatLabels labels the branches in lists as --format asks. Without it,
+// labels stay empty and options show the usual branch details.
+func formatLabels(ctx context.Context, opts options, lists ...[]branch) error {
+	if opts.format == "" {
+		return nil
+	}
+
+	// Labels may span lines, so records end in NUL as in pkg/branches; refs
+	// can't contain tabs
+	output, err := gitOutput(ctx, "for-each-ref", "--format=%(refname)%09"+opts.format+"%00", "refs/heads", "refs/remotes", "refs/tags")
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]string{}
+	for _, record := range strings.Split(string(output), "\x00") {
+		// for-each-ref ends every record with a newline of its own
+		if ref, label, ok := strings.Cut(strings.TrimPrefix(record, "\n"), "\t"); ok {
+			labels[ref] = label
+		}
+	}
+	for _, branches := range lists {
+		for i := range branches {
+			branches[i].label = labels[branches[i].ref()]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitError is a git command that ran but failed. git has usually shown its
+// stderr on the terminal already; it is kept here to tell failures apart.
+type gitError struct {
+	args     []string
+	stderr   string
+	exitCode int
+	err      *exec.ExitError
+}
+
+// newGitError wraps err from running git with args. Errors other than a
+// failed git, such as git not being found, are returned as is.
+func newGitError(args []string, stderr string, err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	return &gitError{args: args, stderr: stderr, exitCode: exitErr.ExitCode(), err: exitErr}
+}
+
+func (e *gitError) Error() string {
+	message := strings.TrimSpace(e.stderr)
+	if message == "" {
+		message = e.err.Error()
+	}
+	return fmt.Sprintf("git %s: %s", strings.Join(e.args, " "), message)
+}
+
+func (e *gitError) Unwrap() error {
+	return e.err
+}
+
+// notFound reports whether git failed because a branch or commit it was
+// given doesn't exist.
+func (e *gitError) notFound() bool {
+	return strings.Contains(e.stderr, "invalid reference") ||
+		strings.Contains(e.stderr, "did not match any") ||
+		strings.Contains(e.stderr, "unknown revision")
+}
+
+// notFullyMerged reports whether git branch -d refused to delete a branch
+// because its commits would be lost.
+func notFullyMerged(stderr string) bool {
+	return strings.Contains(stderr, "not fully merged")
+}
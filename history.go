@@ -0,0 +1,89 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// historyLimit caps the number of entries kept per repository
+	historyLimit = 50
+	// historyFloat is how many recently used branches are listed first
+	historyFloat = 5
+)
+
+// historyEntry records a successful switch to a branch.
+type historyEntry struct {
+	Repo   string    `json:"repo"`
+	Branch string    `json:"branch"`
+	Time   time.Time `json:"time"`
+}
+
+// loadHistory reads the history file, newest entries first. A missing or
+// unreadable file yields an empty history.
+func loadHistory() []historyEntry {
+	var entries []historyEntry
+	if !loadJSON("history.json", &entries) {
+		return nil
+	}
+	return entries
+}
+
+func saveHistory(entries []historyEntry) error {
+	return saveJSON("history.json", entries)
+}
+
+func getRepoRoot() (string, error) {
+	output, err := gitCommand("rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// recordHistory adds current to the history. History is best effort and
+// never fails a switch.
+func recordHistory(current string) {
+	repo, err := getRepoRoot()
+	if err != nil {
+		return
+	}
+
+	entries := slices.DeleteFunc(loadHistory(), func(e historyEntry) bool {
+		return e.Repo == repo && e.Branch == current
+	})
+	entries = slices.Insert(entries, 0, historyEntry{Repo: repo, Branch: current, Time: time.Now()})
+
+	// Keep only the newest entries of this repository
+	kept := 0
+	entries = slices.DeleteFunc(entries, func(e historyEntry) bool {
+		if e.Repo != repo {
+			return false
+		}
+		kept++
+		return kept > historyLimit
+	})
+
+	_ = saveHistory(entries)
+}
+
+// recentBranches returns up to historyFloat of branches, most recently
+// switched to first, leaving out current. Entries for branches that no
+// longer exist are pruned from the history.
+func recentBranches(branches []branch, current string) []branch {
+	repo, err := getRepoRoot()
+	if err != nil {
+		return nil
+	}
+
+	entries := loadHistory()
+	recent, pruned := storedBranches(&entries, func(e historyEntry) (string, string) {
+		return e.Repo, e.Branch
+	}, repo, branches, current)
+
+	if pruned {
+		_ = saveHistory(entries)
+	}
+	return recent[:min(len(recent), historyFloat)]
+}
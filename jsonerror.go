@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// jsonErrors is set in main with --json, so that errors are reported as JSON
+// on stderr rather than as styled text.
+var jsonErrors bool
+
+// jsonError is the --json representation of an error.
+type jsonError struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
+
+// exitJSON prints err as a jsonError and exits with code.
+func exitJSON(err error, code int) {
+	output, _ := json.Marshal(jsonError{Error: err.Error(), Code: code})
+	fmt.Fprintln(os.Stderr, string(output))
+	os.Exit(code)
+}
+
+// errorCode is the exit code exitWithStatus uses for err.
+func errorCode(err error) int {
+	var timeoutErr *timeoutError
+	var conflictErr *conflictError
+	var gitErr *gitError
+	var exitErr *exec.ExitError
+	var execErr *exec.Error
+	switch {
+	case interrupted.Err() != nil || killedByInterrupt(err):
+		return exitCodeCancelled
+	case errors.As(err, &timeoutErr):
+		return exitCodeTimeout
+	case errors.As(err, &conflictErr):
+		return exitCodeConflict
+	case errors.As(err, &gitErr):
+		return gitErr.exitCode
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	case errors.As(err, &execErr):
+		return exitCodeNoGit
+	case errors.Is(err, errNotGitRepo):
+		return exitCodeNotGitRepo
+	}
+	return exitCodeError
+}
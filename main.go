@@ -1,22 +1,37 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/huh/spinner"
 	"github.com/charmbracelet/lipgloss"
+	gitbranches "github.com/mfyuu/gh-sw/pkg/branches"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
 )
 
 const (
 	defaultTimeout = 5 * time.Second
+	fetchTimeout   = 60 * time.Second
+	sortRecent     = "-committerdate"
 	helpText       = `Interactively switch to a local branch.
 
 USAGE
@@ -25,408 +40,2686 @@ USAGE
 
 FLAGS
   -a, --all           Select from all branches (local + remote)
-  -c, --create NAME   Create and switch to a new branch
+  -c, --create NAME   Create and switch to a new branch without prompting
   -C, --force-create NAME
                       Create/reset and switch to a new branch
+  --from REF          Start branches created by -c, -C or gh sw NAME at REF
+                      instead of HEAD
   -d, --detach        Detach HEAD at the commit
+  --delete            Select local branches to delete
+  -D, --force         Like --delete, but also delete unmerged branches
+  --no-force          With --delete, don't offer to force delete unmerged
+                      branches
+  --rename            Select a local branch and rename it
+  --worktree          Add a worktree next to the repository for the selected
+                      branches (or NAME) and print its path
   --orphan NAME       Create a new orphan branch
+  --reset-to REF      DANGER: hard-reset the current branch (or NAME, after
+                      switching to it) to REF, listing what will be lost and
+                      asking first; needs --yes without a terminal
+  --onto BRANCH       Rebase the current branch (or NAME, after switching to
+                      it) onto BRANCH; exits with 6 if it stops at conflicts
+  --pin NAME          Always list NAME right below the current branch
+  --unpin NAME        Remove NAME from the pinned branches
   -r, --remote        Select from remote branches (+ current branch)
+  --detach-remote     Detach HEAD at a selected remote branch instead of creating
+                      a local branch for it
+  --no-remote-strip   Pass a selected remote branch to git switch as is, e.g.
+                      origin/main rather than main
+  --hide-current-remote
+                      Don't list the remote branch the current branch tracks
+  --two-step          With -r, select a remote first, then one of its branches
+  -t, --tags          Select a tag to detach HEAD at (after all branches with -a)
+  -R, --recent        Sort branches by most recent commit
+  --sort KEY          Sort branches by name, -name, committerdate, -committerdate
+                      or authorname (default name)
+  -f, --fetch         Fetch and prune remotes before listing remote branches
+  -p, --pattern GLOB  Only list branches matching GLOB, e.g. "feature/*"
+  --scope[=PREFIX]    Only list branches starting with PREFIX/, by default the
+                      top-level directory you are in, e.g. team-a/ in
+                      team-a/src (--scope= clears sw.scope)
+  --exclude GLOB      Hide branches matching GLOB (repeatable)
+  --merged [REF]      Only list branches merged into REF (default HEAD)
+  --no-merged [REF]   Only list branches not merged into REF (default HEAD)
+  --tracked           Only list local branches that have an upstream
+  --untracked         Only list local branches without an upstream
+  --mine              Only list branches whose last commit you authored, per
+                      git config user.email
+  --author EMAIL      Like --mine, but for the author with EMAIL
+  --limit N           Only list the first N branches (of each kind with --all)
+  --format TEMPLATE   Label branches with TEMPLATE, e.g. "{name} ({upstream})"
+  --timeout DURATION  Time limit for listing branches (default 5s, or 60s with
+                      --fetch or --pr-status; 0 disables)
+  --previous [N]      Switch back to the branch checked out N switches ago (default 1)
+  --default           Switch to the default branch (origin/HEAD, else main or master)
+  --latest            Switch to the branch with the most recent commit, local or remote
+  --stdin             Switch to the branch named on stdin
+  --upstream          Switch to the branch the current branch tracks
+  --reflog            Select from branches recently checked out, per the reflog
+  --pr [NUMBER]       Check out a pull request, selecting from open ones if no NUMBER
+  --pr-status         Mark local branches that have an open pull request
+  --stash             Stash local changes before switching and re-apply them after
+  --confirm-dirty     Ask before switching away from uncommitted changes
+  --review            Show uncommitted changes before switching and ask whether
+                      to take them along or stash them
+  --force-switch      DANGER: throw away uncommitted changes to tracked files
+                      when switching (asks first unless --yes is given)
+  -y, --yes           Answer yes to every question, e.g. to create a missing
+                      branch or discard changes with --force-switch
+  --repo PATH         Run in the repository at PATH instead of the current one
+  --submodules        Update submodules after a successful switch
+  --exec COMMAND      Run COMMAND with your shell after a successful switch
+  -n, --dry-run       Print the git commands instead of running them
+  -q, --quiet         Don't show spinners, notices or the switch confirmation
+  --no-preview        Don't show recent commits of the highlighted branch
+  --no-style          Don't use colors or other styling (also with NO_COLOR set)
+  --no-spinner        Don't show a spinner while listing (implied in CI and pipes)
+  --no-history        Don't record this switch in the recent branch history
+  --no-track          Don't set up tracking when creating a branch from a remote one
+  --no-cache          Don't reuse branch lists from the last few seconds
+  --no-current        Don't list the current branch in pickers
+  --no-pin-current    List the current branch in its sorted place rather than
+                      at the top
+  --no-auto           Show the picker even when there is only one branch to pick
+  --no-remember       Neither reuse nor remember the flags of this repository
+  --forget            Forget the flags remembered for this repository
+  --current           Print the current branch
+  --complete [PREFIX] Print local branches starting with PREFIX, one per line
+                      when piped and in columns on a terminal
+  --print             Print the selected branch instead of switching to it
+  --json              Print branches as JSON instead of selecting one
+  --debug             Log each command run, with its duration and exit code
   --help              Show help for command
+  -V, --version       Show the version of gh-sw
+
+ENVIRONMENT
+  GH_SW_TIMEOUT       Default for --timeout, e.g. "15s"
+  GH_SW_EXCLUDE       Comma-separated globs to hide when no --exclude is given
+  GH_SW_THEME         Color theme: dark, light or none (default: detected)
+  NO_COLOR            Disable all styling when set, like --no-style
+  GH_SW_CACHE_TTL     How long to reuse branch lists (default 2s; 0 disables)
+  GH_SW_DEBUG         Log commands like --debug when set
+
+FILES
+  ~/.config/gh-sw/config.json
+                      Defaults for sort, exclude, theme, timeout, fetch,
+                      scope and the git binary
+  git config sw.sort, sw.exclude, sw.autofetch, sw.scope
+                      Per-repository defaults, taking precedence over config.json
 
 EXAMPLES
   $ gh sw              # Interactive branch selection
   $ gh sw feature/auth # Switch to specific branch
+  $ gh sw auth         # Switch to the branch containing "auth", or pick one
   $ gh sw -            # Switch to previous branch
+  $ gh sw --previous 2 # Switch to the branch before the previous one
+  $ gh sw --default    # Switch to the default branch
+  $ gh sw --upstream   # Switch to what the current branch tracks
+  $ gh sw -f --latest  # Fetch, then switch to whatever was committed to last
+  $ gh sw --reflog     # Select from the branches you checked out lately
   $ gh sw -a           # Select from all branches
   $ gh sw -c feature   # Create and switch to new branch
+  $ gh sw -c fix --from origin/main # Create fix from origin/main
   $ gh sw -C feature   # Force create and switch to branch
   $ gh sw -d           # Detach HEAD at current commit
   $ gh sw -d main      # Detach HEAD at main
+  $ gh sw --delete     # Select branches to delete
+  $ gh sw --delete --merged # Select among branches already merged
   $ gh sw --orphan new # Create orphan branch
+  $ cd "$(gh sw --worktree hotfix)" # Work on hotfix in ../hotfix
+  $ gh sw -f --reset-to origin/main # Start the current branch over from main
+  $ gh sw --onto main feature/x # Switch to feature/x and rebase it onto main
   $ gh sw -r           # Select from remote branches
+  $ gh sw -r -f        # Fetch first, then select from remote branches
+  $ gh sw -r --two-step # Select a remote, then one of its branches
+  $ gh sw -R           # Most recently committed branches first
+  $ gh sw --timeout 30s -a # Allow more time on large repos
+  $ gh sw --json -a    # List all branches as JSON
+  $ git rebase $(gh sw --print) # Rebase onto a selected branch
+  $ gh pr view 123 --json headRefName -q .headRefName | gh sw --stdin
+  $ gh sw -p 'fix/*'   # Select from branches under fix/
+  $ gh sw --pr 123     # Switch to the branch of PR #123
+  $ gh sw --pr         # Select from open pull requests
+  $ gh sw --pr-status  # See which branches have a pull request open
+  $ gh sw --repo ~/src/app # Select a branch of another repository
+  $ gh sw --exec 'npm install' feature/deps # Switch, then install dependencies
 `
 )
 
+// The commit preview is hidden on terminals smaller than this
+const (
+	previewMinWidth  = 60
+	previewMinHeight = 20
+)
+
+// Rows taken by a picker besides its select, which pages within the rest
+const (
+	previewHeight = 7 // preview title, commits and the gap above them
+	formChrome    = 3 // help line, picker status line and margin
+	minListHeight = 5
+)
+
 var grayStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 
+// gitPath is the git binary every command runs. It can be set in the config
+// file, and tests point it at a stub.
+var gitPath = "git"
+
+// interrupted is cancelled by ctrl+c while no prompt is open; prompts handle
+// it as a key themselves.
+var interrupted = context.Background()
+
+// repoDir is the absolute path given by --repo, or "" to use the working
+// directory. git is pointed at it with -C, and gh runs in it.
+var repoDir string
+
+// repoArgs prefixes the arguments to git with -C repoDir when --repo is
+// given.
+func repoArgs(args ...string) []string {
+	if repoDir == "" {
+		return args
+	}
+	return append([]string{"-C", repoDir}, args...)
+}
+
+// gitCommand returns the command running git with args in repoDir.
+func gitCommand(args ...string) *command {
+	return &command{exec.Command(gitPath, repoArgs(args...)...)}
+}
+
+// gitCommandContext is gitCommand with a context.
+func gitCommandContext(ctx context.Context, args ...string) *command {
+	return &command{exec.CommandContext(ctx, gitPath, repoArgs(args...)...)}
+}
+
+// Exit codes for wrapper scripts. Failing git commands pass on their own.
+const (
+	exitCodeError      = 1
+	exitCodeNotGitRepo = 2
+	exitCodeNoBranches = 3
+	exitCodeNoGit      = 4
+	exitCodeTimeout    = 5
+	exitCodeConflict   = 6
+	exitCodeCancelled  = 130
+)
+
+var (
+	errDetachedHead = errors.New("HEAD is detached")
+	errNotGitRepo   = errors.New("Not inside a git repository.")
+)
+
+// branch is a ref listed by git for-each-ref along with its last commit.
+type branch struct {
+	name    string
+	date    string
+	subject string
+	// committed is when the last commit was made; date is the same, relative
+	committed time.Time
+	// remote is the remote name of a remote-tracking branch
+	remote string
+	// ahead and behind count commits relative to the upstream, if any
+	ahead  int
+	behind int
+	// worktree is the path of another worktree the branch is checked out in
+	worktree string
+	// tag marks a tag listed with --tags
+	tag bool
+	// label replaces the name and details of the branch with --format
+	label string
+	// description is the first line of branch.<name>.description
+	description string
+	// pr is the number of the open pull request for the branch with
+	// --pr-status
+	pr int
+}
+
+// options holds the flags parsed from the command line.
+type options struct {
+	help         bool
+	version      bool
+	all          bool
+	reflog       bool
+	remote       bool
+	detach       bool
+	detachRemote bool
+	noStrip      bool
+	delete       bool
+	rename       bool
+	worktree     bool
+	force        bool
+	noForce      bool
+	complete     bool
+	current      bool
+	stash        bool
+	forceSwitch  bool
+	yes          bool
+	tags         bool
+	confirmDirty bool
+	review       bool
+	noHistory    bool
+	noTrack      bool
+	hideUpstream bool
+	twoStep      bool
+	noCurrent    bool
+	noPinCurrent bool
+	noAuto       bool
+	noRemember   bool
+	forget       bool
+	noCache      bool
+	fetch        bool
+	pr           bool
+	prNumber     string
+	prStatus     bool
+	previous     int
+	toDefault    bool
+	latest       bool
+	upstream     bool
+	stdin        bool
+	limit        int
+	noPreview    bool
+	noStyle      bool
+	noSpinner    bool
+	dryRun       bool
+	quiet        bool
+	submodules   bool
+	command      string
+	print        bool
+	json         bool
+	create       string
+	forceCreate  string
+	from         string
+	orphan       string
+	resetTo      string
+	onto         string
+	pin          string
+	unpin        string
+	sort         string
+	timeout      string
+	pattern      string
+	scope        string
+	scopeSet     bool
+	merged       string
+	noMerged     string
+	tracked      bool
+	untracked    bool
+	mine         bool
+	author       string
+	format       string
+	exclude      []string
+	branch       string
+}
+
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
-	defer cancel()
+	// Looked for ahead of parsing, so that a bad flag is reported as JSON too
+	jsonErrors = slices.Contains(os.Args[1:], "--json")
+	opts, err := parseArgs(os.Args[1:])
+	if err != nil {
+		exitError(err)
+	}
+
+	if opts.help {
+		fmt.Print(helpText)
+		return
+	}
+
+	if os.Getenv("GH_SW_DEBUG") != "" {
+		debugMode = true
+	}
+
+	if opts.version {
+		fmt.Println("gh-sw " + buildVersion())
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		exitWithStatus(err)
+	}
+	if cfg.Git != "" {
+		gitPath = cfg.Git
+	}
+
+	theme := cmp.Or(os.Getenv("GH_SW_THEME"), cfg.Theme)
+	// Plain text throughout, forms included, per https://no-color.org
+	if opts.noStyle || os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.Ascii)
+		theme = "none"
+	}
+	grayStyle, err = themeStyle(theme)
+	if err != nil {
+		exitError(err)
+	}
+
+	if err := loadText(); err != nil {
+		exitError(err)
+	}
+
+	// A missing git would otherwise pass for not being in a repository
+	if _, err := exec.LookPath(gitPath); err != nil {
+		exitWithStatus(err)
+	}
+
+	// Check up front so that neither reading git config nor a spinner get in
+	// before the friendly message
+	if !insideWorkTree() {
+		exitWithStatus(errNotGitRepo)
+	}
+
+	// Before the flags are combined with the environment and config
+	opts = recallFlags(os.Args[1:], opts)
+
+	if len(opts.exclude) == 0 {
+		opts.exclude, err = excludeFromEnv()
+		if err != nil {
+			exitError(err)
+		}
+	}
+
+	if err := applyConfig(&opts, cfg); err != nil {
+		exitWithStatus(err)
+	}
+	// Said up front, as it hides branches the user may be looking for
+	if opts.scope != "" && !opts.complete && !opts.current && !opts.json {
+		notice(opts, fmt.Sprintf("Scope: %s (pass --scope= to list every branch)", opts.scope))
+	}
+
+	listTimeout, err = resolveTimeout(opts)
+	if err != nil {
+		exitError(err)
+	}
 
-	args := os.Args[1:]
-	if len(args) > 0 {
-		switch args[0] {
+	cacheTTL, err = resolveCacheTTL(opts)
+	if err != nil {
+		exitError(err)
+	}
+
+	opts.author, err = resolveAuthor(opts)
+	if err != nil {
+		exitError(err)
+	}
+
+	// ctrl+c outside of a prompt cancels the running git commands instead of
+	// killing gh-sw on the spot
+	var stop context.CancelFunc
+	interrupted, stop = signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ctx := interrupted
+	if listTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, listTimeout)
+		defer cancel()
+	}
+
+	switch {
+	case opts.current:
+		err = printCurrentBranch()
+	case opts.forget:
+		err = forgetFlags(opts)
+	case opts.complete:
+		err = printCompletions(ctx, opts.branch)
+	case opts.json:
+		err = printJSON(ctx, opts)
+	case opts.create != "":
+		err = createBranch(opts.create, opts)
+	case opts.forceCreate != "":
+		err = forceCreateBranch(opts.forceCreate, opts)
+	case opts.orphan != "":
+		err = orphanBranch(opts.orphan, opts)
+	case opts.pin != "":
+		err = pinBranch(opts.pin, opts)
+	case opts.unpin != "":
+		err = unpinBranch(opts.unpin, opts)
+	case opts.detach:
+		err = detachHead(opts.branch, opts)
+	case opts.delete:
+		interactiveDelete(ctx, opts)
+	case opts.rename:
+		interactiveRename(ctx, opts)
+	case opts.worktree && opts.branch != "":
+		err = addNamedWorktree(ctx, opts.branch, opts)
+	case opts.worktree:
+		interactiveWorktree(ctx, opts)
+	case opts.resetTo != "":
+		err = resetTo(ctx, opts.resetTo, opts.branch, opts)
+	case opts.onto != "":
+		err = rebaseOnto(ctx, opts.onto, opts.branch, opts)
+	case opts.prNumber != "":
+		err = checkoutPR(opts.prNumber, opts)
+	case opts.pr:
+		interactiveSwitchPR(ctx, opts)
+	case opts.previous > 0:
+		err = switchPrevious(opts.previous, opts)
+	case opts.toDefault:
+		err = switchDefault(ctx, opts)
+	case opts.latest:
+		err = switchLatest(ctx, opts)
+	case opts.stdin:
+		err = switchStdin(opts)
+	case opts.upstream:
+		err = switchUpstream(ctx, opts)
+	case opts.branch != "":
+		err = switchNamedBranch(ctx, opts.branch, opts)
+	case opts.reflog:
+		interactiveSwitch(ctx, scopeReflog, opts)
+	case opts.all:
+		interactiveSwitch(ctx, scopeAll, opts)
+	case opts.tags:
+		interactiveSwitch(ctx, scopeTags, opts)
+	case opts.remote:
+		interactiveSwitch(ctx, scopeRemote, opts)
+	default:
+		interactiveSwitch(ctx, scopeLocal, opts)
+	}
+	if err != nil {
+		exitWithStatus(err)
+	}
+
+	// Failed and cancelled switches have exited by now
+	if opts.submodules && switches(opts) {
+		if err := updateSubmodules(interrupted, opts); err != nil {
+			exitWithStatus(err)
+		}
+	}
+	if opts.command != "" && switches(opts) {
+		if err := runExec(opts.command); err != nil {
+			exitWithStatus(err)
+		}
+	}
+}
+
+func parseArgs(args []string) (options, error) {
+	var opts options
+	var err error
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
 		case "--help", "-h":
-			fmt.Print(helpText)
-			return
+			opts.help = true
+		case "--debug":
+			debugMode = true
+		case "--version", "-V":
+			opts.version = true
 		case "--all", "-a":
-			interactiveSwitchAll(ctx)
-			return
-		case "--create", "-c":
-			if len(args) < 2 {
-				fmt.Fprintln(os.Stderr, "error: branch name required")
-				os.Exit(1)
+			opts.all = true
+		case "--reflog":
+			opts.reflog = true
+		case "--remote", "-r":
+			opts.remote = true
+		case "--tags", "-t":
+			opts.tags = true
+		case "--fetch", "-f":
+			opts.fetch = true
+		case "--recent", "-R":
+			opts.sort = sortRecent
+		case "--detach", "-d":
+			opts.detach = true
+		case "--detach-remote":
+			opts.detachRemote = true
+		case "--no-remote-strip":
+			opts.noStrip = true
+		case "--delete":
+			opts.delete = true
+		case "--rename":
+			opts.rename = true
+		case "--worktree":
+			opts.worktree = true
+		case "--complete":
+			opts.complete = true
+		case "--current":
+			opts.current = true
+		case "--no-current":
+			opts.noCurrent = true
+		case "--no-pin-current":
+			opts.noPinCurrent = true
+		case "--no-remember":
+			opts.noRemember = true
+		case "--forget":
+			opts.forget = true
+		case "--no-auto":
+			opts.noAuto = true
+		case "--stash":
+			opts.stash, opts.forceSwitch = true, false
+		case "--force-switch":
+			opts.stash, opts.forceSwitch = false, true
+		case "--yes", "-y":
+			opts.yes = true
+		case "--confirm-dirty":
+			opts.confirmDirty = true
+		case "--review":
+			opts.review = true
+		case "--no-history":
+			opts.noHistory = true
+		case "--no-track":
+			opts.noTrack = true
+		case "--hide-current-remote":
+			opts.hideUpstream = true
+		case "--two-step":
+			opts.twoStep = true
+		case "--no-cache":
+			opts.noCache = true
+		case "--no-preview":
+			opts.noPreview = true
+		case "--no-style":
+			opts.noStyle = true
+		case "--no-spinner":
+			opts.noSpinner = true
+		case "--dry-run", "-n":
+			opts.dryRun = true
+		case "--quiet", "-q":
+			opts.quiet = true
+		case "--print":
+			opts.print = true
+		case "--default":
+			opts.toDefault = true
+		case "--latest":
+			opts.latest = true
+		case "--stdin":
+			opts.stdin = true
+		case "--upstream":
+			opts.upstream = true
+		case "--previous":
+			opts.previous = 1
+			// N is optional and defaults to the last branch
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				n, convErr := strconv.Atoi(args[i])
+				if convErr != nil || n <= 0 {
+					return opts, fmt.Errorf("invalid number of switches: %s (expected a positive integer)", args[i])
+				}
+				opts.previous = n
 			}
-			if err := createBranch(args[1]); err != nil {
-				exitWithStatus(err)
+		case "--pr":
+			opts.pr = true
+			// The number is optional; without it open PRs are listed
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				if n, convErr := strconv.Atoi(args[i]); convErr != nil || n <= 0 {
+					return opts, fmt.Errorf("invalid pull request number: %s", args[i])
+				}
+				opts.prNumber = args[i]
 			}
-			return
+		case "--pr-status":
+			opts.prStatus = true
+		case "--merged", "--no-merged":
+			// The ref is optional and defaults to HEAD, like git branch
+			ref := "HEAD"
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				ref = args[i]
+			}
+			if arg == "--merged" {
+				opts.merged = ref
+			} else {
+				opts.noMerged = ref
+			}
+		case "--mine":
+			opts.mine = true
+		case "--author":
+			opts.author, err = nextArg(args, &i, "author email")
+		case "--tracked":
+			opts.tracked, opts.untracked = true, false
+		case "--untracked":
+			opts.tracked, opts.untracked = false, true
+		case "--json":
+			opts.json = true
+		case "--force", "-D":
+			opts.delete = true
+			opts.force = true
+		case "--no-force":
+			opts.noForce = true
+		case "--create", "-c":
+			opts.create, err = nextArg(args, &i, "branch name")
 		case "--force-create", "-C":
-			if len(args) < 2 {
-				fmt.Fprintln(os.Stderr, "error: branch name required")
-				os.Exit(1)
+			opts.forceCreate, err = nextArg(args, &i, "branch name")
+		case "--from":
+			opts.from, err = nextArg(args, &i, "start point")
+		case "--orphan":
+			opts.orphan, err = nextArg(args, &i, "branch name")
+		case "--reset-to":
+			opts.resetTo, err = nextArg(args, &i, "ref")
+		case "--onto":
+			opts.onto, err = nextArg(args, &i, "branch to rebase onto")
+		case "--pin":
+			opts.pin, err = nextArg(args, &i, "branch name")
+		case "--unpin":
+			opts.unpin, err = nextArg(args, &i, "branch name")
+		case "--sort":
+			var key string
+			key, err = nextArg(args, &i, "sort key")
+			if err == nil {
+				opts.sort, err = parseSortKey(key)
 			}
-			if err := forceCreateBranch(args[1]); err != nil {
-				exitWithStatus(err)
+		case "--limit":
+			var limit string
+			limit, err = nextArg(args, &i, "limit")
+			if err == nil {
+				opts.limit, err = strconv.Atoi(limit)
+				if err != nil || opts.limit <= 0 {
+					return opts, fmt.Errorf("invalid limit: %s (expected a positive integer)", limit)
+				}
 			}
-			return
-		case "--detach", "-d":
-			startPoint := ""
-			if len(args) >= 2 {
-				startPoint = args[1]
+		case "--timeout":
+			opts.timeout, err = nextArg(args, &i, "timeout")
+		case "--submodules":
+			opts.submodules = true
+		case "--exec":
+			opts.command, err = nextArg(args, &i, "command")
+		case "--format":
+			var template string
+			template, err = nextArg(args, &i, "format")
+			if err == nil {
+				opts.format, err = parseFormat(template)
 			}
-			if err := detachHead(startPoint); err != nil {
-				exitWithStatus(err)
+		case "--repo":
+			var dir string
+			dir, err = nextArg(args, &i, "repository path")
+			if err == nil {
+				repoDir, err = filepath.Abs(dir)
 			}
-			return
-		case "--orphan":
-			if len(args) < 2 {
-				fmt.Fprintln(os.Stderr, "error: branch name required")
-				os.Exit(1)
+		case "--scope":
+			opts.scope, opts.scopeSet = scopeHere, true
+		case "--pattern", "-p":
+			opts.pattern, err = nextArg(args, &i, "pattern")
+			if err == nil {
+				_, err = path.Match(opts.pattern, "")
 			}
-			if err := orphanBranch(args[1]); err != nil {
-				exitWithStatus(err)
+		case "--exclude":
+			var pattern string
+			pattern, err = nextArg(args, &i, "pattern")
+			if err == nil {
+				_, err = path.Match(pattern, "")
 			}
-			return
-		case "--remote", "-r":
-			interactiveSwitchRemote(ctx)
-			return
+			opts.exclude = append(opts.exclude, pattern)
+		default:
+			if scope, ok := strings.CutPrefix(arg, "--scope="); ok {
+				opts.scope, opts.scopeSet = scope, true
+				continue
+			}
+			// "-" is passed through to git as the previous branch
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				return opts, fmt.Errorf("unknown flag: %s", arg)
+			}
+			if opts.branch != "" {
+				return opts, fmt.Errorf("unexpected argument: %s", arg)
+			}
+			opts.branch = arg
 		}
-		if err := switchBranch(args[0]); err != nil {
-			exitWithStatus(err)
+		if err != nil {
+			return opts, err
 		}
-		return
+	}
+	// gh pr checkout has no way to throw local changes away
+	if opts.pr && opts.forceSwitch {
+		return opts, errors.New("--force-switch can't be used with --pr")
+	}
+	return opts, nil
+}
+
+// sortKeys maps the --sort values to git for-each-ref sort keys.
+var sortKeys = map[string]string{
+	"name":           "refname",
+	"-name":          "-refname",
+	"committerdate":  "committerdate",
+	"-committerdate": "-committerdate",
+	"authorname":     "authorname",
+}
+
+func parseSortKey(key string) (string, error) {
+	sortKey, ok := sortKeys[key]
+	if !ok {
+		return "", fmt.Errorf("invalid sort key: %s (expected name, -name, committerdate, -committerdate or authorname)", key)
+	}
+	return sortKey, nil
+}
+
+// nextArg consumes the value following the flag at args[*i].
+func nextArg(args []string, i *int, name string) (string, error) {
+	if *i+1 >= len(args) {
+		return "", errors.New(name + " required")
+	}
+	*i++
+	return args[*i], nil
+}
+
+// resolveTimeout returns the timeout given by --timeout, falling back to
+// GH_SW_TIMEOUT and then a default that allows for --fetch and --pr-status
+// going over the network. Zero means no timeout.
+func resolveTimeout(opts options) (time.Duration, error) {
+	value := opts.timeout
+	if value == "" {
+		value = os.Getenv("GH_SW_TIMEOUT")
+	}
+	if value == "" {
+		if opts.fetch || opts.prStatus {
+			return fetchTimeout, nil
+		}
+		return defaultTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid timeout: %s", value)
+	}
+	return timeout, nil
+}
+
+func insideWorkTree() bool {
+	output, err := gitCommand("rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// unbornHead reports whether HEAD is on a branch without any commits yet,
+// as in a repository that was just initialized. Such a branch is not listed
+// by git for-each-ref.
+func unbornHead() bool {
+	return gitCommand("rev-parse", "--verify", "--quiet", "HEAD").Run() != nil
+}
+
+// themeStyle returns the style for secondary text in the given theme,
+// picking dark or light from the terminal background when unset.
+func themeStyle(theme string) (lipgloss.Style, error) {
+	if theme == "" {
+		theme = "light"
+		if lipgloss.HasDarkBackground() {
+			theme = "dark"
+		}
+	}
+
+	switch theme {
+	case "dark":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("240")), nil
+	case "light":
+		// #767676 keeps a 4.5:1 contrast ratio on white
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("243")), nil
+	case "none":
+		return lipgloss.NewStyle(), nil
+	}
+	return lipgloss.Style{}, fmt.Errorf("invalid theme: %s (want dark, light or none)", theme)
+}
+
+// excludeFromEnv reads the comma-separated globs in GH_SW_EXCLUDE, used when
+// no --exclude flag is given.
+func excludeFromEnv() ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(os.Getenv("GH_SW_EXCLUDE"), ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid GH_SW_EXCLUDE pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// getCurrentBranch returns the checked out branch, or errDetachedHead when
+// HEAD points directly at a commit.
+func getCurrentBranch() (string, error) {
+	cmd := gitCommand("symbolic-ref", "--quiet", "--short", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		// symbolic-ref exits 1 when HEAD is not a symbolic ref
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", errDetachedHead
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// getUpstream returns the remote branch that branch tracks, e.g.
+// origin/main, or "" if it tracks none.
+func getUpstream(branch string) string {
+	output, err := gitCommand("rev-parse", "--abbrev-ref", branch+"@{upstream}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func getLocalBranches(ctx context.Context, sortKey string) ([]branch, error) {
+	list, err := gitbranches.ListLocal(ctx, gitOutput, sortKey)
+	return fromList(list, false), err
+}
+
+func getRemoteBranches(ctx context.Context, sortKey string) ([]branch, error) {
+	list, err := gitbranches.ListRemote(ctx, gitOutput, sortKey)
+	return fromList(list, false), err
+}
+
+func getTags(ctx context.Context, sortKey string) ([]branch, error) {
+	list, err := gitbranches.ListTags(ctx, gitOutput, sortKey)
+	return fromList(list, true), err
+}
+
+// fromList converts refs listed by package branches, marking them as tags
+// if tag is set.
+func fromList(list []gitbranches.Branch, tag bool) []branch {
+	var refs []branch
+	for _, b := range list {
+		refs = append(refs, branch{
+			name:      b.Name,
+			date:      b.Date,
+			subject:   b.Subject,
+			committed: b.Committed,
+			remote:    b.Remote,
+			ahead:     b.Ahead,
+			behind:    b.Behind,
+			tag:       tag,
+		})
+	}
+	return refs
+}
+
+func getRemotes(ctx context.Context) ([]string, error) {
+	output, err := gitCommandContext(ctx, "remote").Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// columns is the layout of branch options: the terminal width they must fit
+// in and the width names are padded to, so that the gray commit details of
+// all options start in the same column.
+type columns struct {
+	width int
+	name  int
+}
+
+// branchColumns lays out the options for branches, of which pinned get a
+// star in front. Names are only padded when the terminal width is known, and
+// to at most half of it so one long name doesn't squeeze every subject.
+func branchColumns(branches, pinned []branch) columns {
+	c := columns{width: terminalWidth()}
+	if c.width == 0 {
+		return c
+	}
+	for _, b := range branches {
+		c.name = max(c.name, lipgloss.Width(branchLabel(b)))
+	}
+	for _, b := range pinned {
+		c.name = max(c.name, lipgloss.Width(branchLabel(b))+2)
+	}
+	c.name = min(c.name, c.width/2)
+	return c
+}
+
+// branchLabel is the name of the branch, colored by age, followed by its
+// gray markers: how far it is ahead of and behind its upstream, the worktree
+// it is checked out in, its open pull request and whether it is a tag.
+func branchLabel(b branch) string {
+	label := ageStyle(b.committed, time.Now()).Render(b.name)
+	var track []string
+	if b.ahead > 0 {
+		track = append(track, fmt.Sprintf("↑%d", b.ahead))
+	}
+	if b.behind > 0 {
+		track = append(track, fmt.Sprintf("↓%d", b.behind))
+	}
+	if len(track) > 0 {
+		label += " " + grayStyle.Render(strings.Join(track, " "))
+	}
+	if b.worktree != "" {
+		label += " " + grayStyle.Render("(in "+b.worktree+")")
+	}
+	if b.pr > 0 {
+		label += " " + grayStyle.Render(fmt.Sprintf("PR #%d", b.pr))
+	}
+	if b.tag {
+		// Keep tags apart from branches of the same name
+		label += " " + grayStyle.Render("tag")
+	}
+	return label
+}
+
+// ageStyle colors a branch by the age of its last commit so that stale ones
+// stand out: green within a week, plain within a month and gray after that.
+// Branches without a known date stay plain.
+func ageStyle(committed, now time.Time) lipgloss.Style {
+	switch age := now.Sub(committed); {
+	case committed.IsZero():
+		return lipgloss.NewStyle()
+	case age < 7*24*time.Hour:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	case age < 30*24*time.Hour:
+		return lipgloss.NewStyle()
+	default:
+		return grayStyle
+	}
+}
+
+// branchOption builds a select option labelled with the branch's last commit
+// subject and date in gray, lined up according to cols, and its description
+// if it has one. The value stays the plain branch name. A --format label is
+// used as is instead.
+func branchOption(b branch, cols columns) huh.Option[string] {
+	name := branchLabel(b)
+	value := b.name
+	if b.tag {
+		value = "refs/tags/" + b.name
+	}
+	if b.label != "" {
+		return huh.NewOption(b.label, value)
+	}
+
+	var description string
+	if b.description != "" {
+		description = " · " + b.description
+	}
+
+	if b.date == "" && b.subject == "" {
+		if description != "" {
+			name += " " + grayStyle.Render(b.description)
+		}
+		return huh.NewOption(name, value)
+	}
+
+	if pad := cols.name - lipgloss.Width(name); pad > 0 {
+		name += strings.Repeat(" ", pad)
+	}
+	meta := b.date
+	if b.subject != "" {
+		subject := b.subject
+		if cols.width > 0 {
+			// Leave room for the select cursor and the column gap
+			subject = truncate(subject, cols.width-lipgloss.Width(name)-lipgloss.Width(" · "+b.date+description)-7)
+		}
+		meta = subject + " · " + b.date
+	}
+	return huh.NewOption(name+"   "+grayStyle.Render(meta+description), value)
+}
+
+// headerOption builds a gray group header. Its value is empty, which
+// branchForm refuses to submit.
+func headerOption(title string) huh.Option[string] {
+	return huh.NewOption(grayStyle.Render("── "+title+" ──"), "")
+}
+
+// moreOption builds the gray note ending a list cut short by --limit.
+func moreOption(hidden int) huh.Option[string] {
+	return huh.NewOption(grayStyle.Render(fmt.Sprintf("… and %d more (raise --limit to list them)", hidden)), "")
+}
+
+// currentOption builds the gray entry of the current branch, marked with *.
+// Filtering is case-insensitive and the label still contains the plain
+// branch name, so the styled entry matches like any other.
+func currentOption(current string) huh.Option[string] {
+	return huh.NewOption(grayStyle.Render("* "+current), current)
+}
+
+// pinCurrent reports whether the current branch goes at the top of a picker
+// listing branches: always, unless --no-pin-current leaves it in its sorted
+// place among them.
+func pinCurrent(branches []branch, current string, opts options) bool {
+	return current != "" && (!opts.noPinCurrent || !slices.ContainsFunc(branches, hasName(current)))
+}
+
+// branchForm builds the branch picker and the filter of its options. Unless
+// disabled or the terminal is too small, the recent commits of the
+// highlighted branch are shown below it.
+func branchForm(title string, options []huh.Option[string], selected *string, opts options) (*huh.Form, *optionFilter) {
+	// huh starts on the option matching the value, which would be a
+	// header while it is empty
+	if *selected == "" && len(options) > 0 {
+		*selected = options[0].Value
+	}
+
+	width, height, err := term.GetSize(int(os.Stderr.Fd()))
+	preview := !opts.noPreview && err == nil && width >= previewMinWidth && height >= previewMinHeight
+	reserved := formChrome
+	if preview {
+		reserved += previewHeight
+	}
+
+	field := huh.NewSelect[string]().
+		Title(title).
+		Options(options...).
+		Height(listHeight(len(options), reserved)).
+		Validate(func(value string) error {
+			if value == "" {
+				return errors.New("select a branch")
+			}
+			return nil
+		}).
+		Value(selected)
+	fields := []huh.Field{field}
+
+	if preview {
+		fields = append(fields, huh.NewNote().
+			Title(text.RecentCommits).
+			DescriptionFunc(func() string {
+				return branchPreview(*selected)
+			}, selected))
+	}
+
+	filter := &optionFilter{field: field, title: title, options: options, selected: selected}
+	return huh.NewForm(huh.NewGroup(fields...)), filter
+}
+
+// branchPreview returns the last few commits of branch, one per line.
+func branchPreview(branch string) string {
+	if branch == "" {
+		return ""
+	}
+	cmd := gitCommand("log", "--oneline", "--no-decorate", "--color=never", "-5", branch, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(output), "\n")
+}
+
+// truncate shortens s to at most width cells, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	if width <= 1 {
+		return ""
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes)) > width-1 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// terminalWidth returns the width of stderr, where the picker is drawn, or 0
+// when it is not a terminal.
+func terminalWidth() int {
+	width, _, err := term.GetSize(int(os.Stderr.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// listHeight is the height for a select of n options that leaves reserved
+// rows of the terminal to the rest of the form, so that long lists page
+// instead of rendering every option. It is 0, for no limit, when the options
+// fit or the terminal size is unknown.
+func listHeight(n, reserved int) int {
+	_, height, err := term.GetSize(int(os.Stderr.Fd()))
+	if err != nil {
+		return 0
+	}
+	// The title takes a row too
+	if rows := height - reserved; n+1 > rows {
+		return max(rows, minListHeight)
+	}
+	return 0
+}
+
+// printCurrentBranch prints the name of the current branch. On a detached
+// HEAD it silently exits with an error instead.
+func printCurrentBranch() error {
+	current, err := getCurrentBranch()
+	if errors.Is(err, errDetachedHead) {
+		os.Exit(exitCodeError)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(current)
+	return nil
+}
+
+// printCompletions prints the local branches starting with prefix, one per
+// line for use by shell completion scripts, or in columns on a terminal.
+func printCompletions(ctx context.Context, prefix string) error {
+	branches, err := getLocalBranches(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, branch := range branches {
+		if strings.HasPrefix(branch.name, prefix) {
+			names = append(names, branch.name)
+		}
+	}
+	printColumns(names)
+	return nil
+}
+
+// jsonBranch is the --json representation of a branch.
+type jsonBranch struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	Remote  bool   `json:"remote"`
+}
+
+// printJSON prints the branches the interactive mode selected by opts would
+// offer as a JSON array, local branches first.
+func printJSON(ctx context.Context, opts options) error {
+	current, _ := getCurrentBranch()
+
+	result := []jsonBranch{}
+	if opts.all || !opts.remote {
+		branches, err := getLocalBranches(ctx, opts.sort)
+		if err != nil {
+			return checkTimeout(ctx, err, "fetching branches")
+		}
+		for _, branch := range branches {
+			result = append(result, jsonBranch{Name: branch.name, Current: branch.name == current})
+		}
+	}
+	if opts.all || opts.remote {
+		branches, err := getRemoteBranches(ctx, opts.sort)
+		if err != nil {
+			return checkTimeout(ctx, err, "fetching remote branches")
+		}
+		for _, branch := range branches {
+			result = append(result, jsonBranch{Name: branch.name, Remote: true})
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// scope is the kind of refs an interactive switch selects from.
+type scope int
+
+const (
+	scopeLocal scope = iota
+	scopeRemote
+	scopeAll
+	scopeTags
+	scopeReflog
+)
+
+// title is the prompt of the picker for s.
+func (s scope) title() string {
+	switch s {
+	case scopeRemote:
+		return text.SelectRemoteBranch
+	case scopeTags:
+		return text.SelectTag
+	case scopeReflog:
+		return text.SelectVisited
+	default:
+		return text.SelectBranch
+	}
+}
+
+// emptyText is shown when s has nothing to select from.
+func (s scope) emptyText() string {
+	switch s {
+	case scopeLocal:
+		return text.NoLocalBranches
+	case scopeRemote:
+		return text.NoRemoteBranches
+	case scopeTags:
+		return text.NoTags
+	case scopeReflog:
+		return text.NoVisited
+	default:
+		return text.NoBranches
+	}
+}
+
+// refList holds the refs offered by an interactive switch.
+type refList struct {
+	local  []branch
+	remote []branch
+	tags   []branch
+}
+
+func (r refList) empty() bool {
+	return len(r.local) == 0 && len(r.remote) == 0 && len(r.tags) == 0
+}
+
+// interactiveSwitch lets the user pick one of the refs in s and switches
+// to it.
+func interactiveSwitch(ctx context.Context, s scope, opts options) {
+	refs, err := fetchScope(ctx, s, opts)
+
+	if err != nil {
+		exitWithStatus(err)
+	}
+
+	if refs.empty() {
+		message := noBranchesMessage(opts, s.emptyText())
+		unborn := s == scopeLocal && unbornHead()
+		if unborn {
+			message = text.NoCommits
+		}
+		// Offer to start a branch, e.g. in a new repository, unless scripted
+		if s == scopeLocal && !opts.print && stdinIsTerminal() {
+			promptCreateBranch(message, !unborn, opts)
+			return
+		}
+		if unborn {
+			message += " Run `gh sw -c NAME` to name the first branch."
+		}
+		exitNoBranches(opts, message)
+	}
+
+	selectRef(ctx, s, refs, opts)
+}
+
+// promptCreateBranch asks for the name of a new branch, explaining why with
+// message, and where to start it if startPoint is set, then switches to it.
+func promptCreateBranch(message string, startPoint bool, opts options) {
+	var name string
+	fields := []huh.Field{
+		huh.NewInput().
+			Title(text.CreateBranch).
+			Description(message).
+			Value(&name).
+			Validate(validBranchName),
+	}
+	if startPoint {
+		fields = append(fields, huh.NewInput().
+			Title(text.StartPoint).
+			Description(text.StartPointHint).
+			Value(&opts.from).
+			Validate(validStartPoint))
+	}
+	err := huh.NewForm(huh.NewGroup(fields...)).Run()
+	if err != nil {
+		exitCancelled(opts)
+	}
+
+	if err := createBranch(name, opts); err != nil {
+		exitWithStatus(err)
+	}
+}
+
+// fetchScope lists the refs in s. Tags are only part of scopeAll with
+// --tags.
+func fetchScope(ctx context.Context, s scope, opts options) (refList, error) {
+	var refs refList
+	var err error
+
+	switch s {
+	case scopeLocal:
+		refs.local, err = fetchLocalBranches(ctx, opts)
+	case scopeRemote:
+		refs.remote, err = fetchRemoteBranches(ctx, opts)
+	case scopeTags:
+		refs.tags, err = fetchTags(ctx, opts)
+	case scopeReflog:
+		refs.local, err = fetchReflogBranches(ctx, opts)
+	case scopeAll:
+		refs.local, refs.remote, err = fetchAllBranches(ctx, opts)
+		if err == nil && opts.tags {
+			refs.tags, err = fetchTags(ctx, opts)
+		}
+	}
+
+	return refs, err
+}
+
+// selectRef runs the picker for refs and switches to the selection, or
+// prints it with --print. Local branches get the picker that can delete
+// them in place, and a lone branch besides the current one is taken without
+// asking unless --no-auto is given.
+func selectRef(ctx context.Context, s scope, refs refList, opts options) {
+	current, err := getCurrentBranch()
+	if errors.Is(err, errDetachedHead) {
+		notice(opts, text.DetachedHead)
+	}
+	if opts.hideUpstream && current != "" {
+		if upstream := getUpstream(current); upstream != "" {
+			refs.remote = slices.DeleteFunc(refs.remote, hasName(upstream))
+		}
+	}
+	if opts.noCurrent {
+		refs.local, current = withoutCurrent(refs.local, current)
+		if refs.empty() {
+			exitNoBranches(opts, noBranchesMessage(opts, s.emptyText()))
+		}
+	}
+
+	if err := formatLabels(ctx, opts, refs.local, refs.remote, refs.tags); err != nil {
+		exitWithStatus(err)
+	}
+
+	if s == scopeRemote && opts.twoStep {
+		refs.remote = selectRemote(refs.remote, opts)
+	}
+
+	ref, ok := onlyRef(refs, current)
+	if ok && !opts.noAuto {
+		notice(opts, fmt.Sprintf("Only one branch: switching to %s", ref.name))
+	} else {
+		var selected string
+		if s == scopeLocal {
+			selected, err = runLocalPicker(refs.local, current, opts)
+		} else {
+			err = runBranchForm(s.title(), scopeOptions(s, refs, current, opts), &selected, opts)
+		}
+		if err != nil {
+			exitCancelled(opts)
+		}
+		ref = resolveSelection(selected, refs)
+	}
+
+	if opts.print {
+		fmt.Println(ref.name)
+		return
+	}
+
+	switch {
+	case ref.tag:
+		err = detachHead("refs/tags/"+ref.name, opts)
+	case ref.remote != "":
+		err = switchRemoteBranch(ref, opts)
+	default:
+		err = switchBranch(ref.name, opts)
+	}
+	if err != nil {
+		exitWithStatus(err)
+	}
+}
+
+// selectRemote asks which remote to pick a branch from for --two-step and
+// returns the branches on it. With a single remote there is nothing to ask.
+func selectRemote(branches []branch, opts options) []branch {
+	var remotes []string
+	count := map[string]int{}
+	for _, branch := range branches {
+		if count[branch.remote] == 0 {
+			remotes = append(remotes, branch.remote)
+		}
+		count[branch.remote]++
+	}
+	if len(remotes) < 2 {
+		return branches
+	}
+	slices.Sort(remotes)
+
+	var options []huh.Option[string]
+	for _, remote := range remotes {
+		label := remote + grayStyle.Render(fmt.Sprintf(" (%d)", count[remote]))
+		options = append(options, huh.NewOption(label, remote))
+	}
+	var selected string
+	err := huh.NewSelect[string]().
+		Title(text.SelectRemote).
+		Options(options...).
+		Filtering(true).
+		Value(&selected).
+		Run()
+	if err != nil {
+		exitCancelled(opts)
+	}
+
+	return slices.DeleteFunc(branches, func(b branch) bool {
+		return b.remote != selected
+	})
+}
+
+// withoutCurrent leaves the current branch out of branches for --no-current.
+// It returns no current branch either, so that none is listed first.
+func withoutCurrent(branches []branch, current string) ([]branch, string) {
+	return slices.DeleteFunc(branches, hasName(current)), ""
+}
+
+// onlyRef returns the one ref in refs besides current, if there is exactly
+// one, which is then switched to without asking.
+func onlyRef(refs refList, current string) (branch, bool) {
+	others := slices.DeleteFunc(slices.Clone(refs.local), hasName(current))
+	others = slices.Concat(others, refs.remote, refs.tags)
+	if len(others) != 1 {
+		return branch{}, false
+	}
+	return others[0], true
+}
+
+// resolveSelection maps the value of the selected option back to the ref
+// it stands for. The current branch and local branches come back as a
+// plain branch.
+func resolveSelection(value string, refs refList) branch {
+	if name, ok := strings.CutPrefix(value, "refs/tags/"); ok {
+		return branch{name: name, tag: true}
+	}
+	if idx := slices.IndexFunc(refs.remote, hasName(value)); idx != -1 {
+		return refs.remote[idx]
+	}
+	return branch{name: value}
+}
+
+// scopeOptions lists the current branch first, then the refs of s: local
+// branches (pinned ones first), remote branches and tags. With --all,
+// several remotes and the tags each get a header.
+func scopeOptions(s scope, refs refList, current string, opts options) []huh.Option[string] {
+	if s == scopeLocal {
+		return localOptions(refs.local, current, opts)
+	}
+
+	localBranches, hiddenLocal := limitBranches(refs.local, current, opts.limit)
+	remoteBranches, hiddenRemote := limitBranches(refs.remote, current, opts.limit)
+	tags, hiddenTags := limitBranches(refs.tags, "", opts.limit)
+
+	var options []huh.Option[string]
+	// Add current branch first
+	if pinCurrent(localBranches, current, opts) {
+		options = append(options, currentOption(current))
+	}
+	// Add pinned branches next, then the other local branches. --reflog
+	// keeps its own order.
+	var pinned []branch
+	if s == scopeAll {
+		pinned = pinnedBranches(localBranches, current)
+	}
+	cols := branchColumns(slices.Concat(localBranches, remoteBranches, tags), pinned)
+	for _, branch := range pinned {
+		options = append(options, pinnedOption(branch, cols))
+	}
+	for _, branch := range localBranches {
+		switch {
+		case branch.name == current:
+			if opts.noPinCurrent {
+				options = append(options, currentOption(current))
+			}
+		case !slices.ContainsFunc(pinned, hasName(branch.name)):
+			options = append(options, branchOption(branch, cols))
+		}
+	}
+	// Add remote branches. --all groups them under a header per remote
+	// when there are several.
+	var remotes []string
+	for _, branch := range remoteBranches {
+		if !slices.Contains(remotes, branch.remote) {
+			remotes = append(remotes, branch.remote)
+		}
+	}
+	if s != scopeAll || len(remotes) < 2 {
+		for _, branch := range remoteBranches {
+			options = append(options, branchOption(branch, cols))
+		}
+		remotes = nil
+	}
+	for _, remote := range remotes {
+		options = append(options, headerOption(remote))
+		for _, branch := range remoteBranches {
+			if branch.remote == remote {
+				options = append(options, branchOption(branch, cols))
+			}
+		}
+	}
+	// Add tags last, in a group of their own
+	if s == scopeAll && len(tags) > 0 {
+		options = append(options, headerOption("tags"))
+	}
+	for _, tag := range tags {
+		options = append(options, branchOption(tag, cols))
+	}
+	if hidden := hiddenLocal + hiddenRemote + hiddenTags; hidden > 0 {
+		options = append(options, moreOption(hidden))
+	}
+
+	return options
+}
+
+// localOptions lists the current branch first, then pinned and recently used
+// branches, then the rest.
+func localOptions(branches []branch, current string, opts options) []huh.Option[string] {
+	branches, hidden := limitBranches(branches, current, opts.limit)
+
+	var options []huh.Option[string]
+	// Add current branch first
+	if pinCurrent(branches, current, opts) {
+		options = append(options, currentOption(current))
+	}
+	// Add pinned branches next, then recently used ones, then the rest
+	pinned := pinnedBranches(branches, current)
+	cols := branchColumns(branches, pinned)
+	for _, branch := range pinned {
+		options = append(options, pinnedOption(branch, cols))
+	}
+	recent := slices.DeleteFunc(recentBranches(branches, current), func(b branch) bool {
+		return slices.ContainsFunc(pinned, hasName(b.name))
+	})
+	for _, branch := range recent {
+		options = append(options, branchOption(branch, cols))
+	}
+	for _, branch := range branches {
+		switch {
+		case branch.name == current:
+			if opts.noPinCurrent {
+				options = append(options, currentOption(current))
+			}
+		case !slices.ContainsFunc(pinned, hasName(branch.name)) && !slices.ContainsFunc(recent, hasName(branch.name)):
+			options = append(options, branchOption(branch, cols))
+		}
+	}
+	if hidden > 0 {
+		options = append(options, moreOption(hidden))
+	}
+
+	return options
+}
+
+// localName is the name of the branch without its remote, which is what a
+// local branch created from it is called.
+func (b branch) localName() string {
+	return gitbranches.Branch{Name: b.name, Remote: b.remote}.LocalName()
+}
+
+// ref is the full name of the ref b stands for.
+func (b branch) ref() string {
+	switch {
+	case b.tag:
+		return "refs/tags/" + b.name
+	case b.remote != "":
+		return "refs/remotes/" + b.name
+	default:
+		return "refs/heads/" + b.name
+	}
+}
+
+func hasName(name string) func(branch) bool {
+	return func(b branch) bool {
+		return b.name == name
+	}
+}
+
+// switchRemoteBranch switches to the local branch of a remote branch:
+// origin/feature/auth -> feature/auth. Rather than leaving git to guess, a
+// missing local branch is created explicitly from the remote branch. With
+// --detach-remote the remote branch is checked out on a detached HEAD, and
+// with --no-remote-strip its full name is passed to git switch as is.
+func switchRemoteBranch(b branch, opts options) error {
+	if opts.detachRemote {
+		return detachHead("refs/remotes/"+b.name, opts)
+	}
+
+	if opts.noStrip {
+		confirmDirty(opts)
+		previous, _ := getCurrentBranch()
+		if err := runGit(opts, switchArgs(opts, b.name)...); err != nil {
+			return err
+		}
+		afterSwitch(previous, opts)
+		return nil
+	}
+
+	name := b.localName()
+	if localBranchExists(name) {
+		return switchBranch(name, opts)
+	}
+
+	previous, _ := getCurrentBranch()
+	if err := trackBranch(name, b.name, opts); err != nil {
+		return err
+	}
+	afterSwitch(previous, opts)
+	return nil
+}
+
+func interactiveDelete(ctx context.Context, opts options) {
+	branches, err := fetchLocalBranches(ctx, opts)
+
+	if err != nil {
+		exitWithStatus(err)
+	}
+
+	if err := formatLabels(ctx, opts, branches); err != nil {
+		exitWithStatus(err)
+	}
+
+	current, _ := getCurrentBranch()
+
+	var options []huh.Option[string]
+	// The current branch cannot be deleted, so leave it out entirely
+	cols := branchColumns(branches, nil)
+	for _, branch := range branches {
+		if branch.name != current {
+			options = append(options, branchOption(branch, cols))
+		}
+	}
+
+	if len(options) == 0 {
+		exitNoBranches(opts, text.NoBranchesToDelete)
+	}
+
+	var selected []string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(text.SelectDelete).
+				Options(options...).
+				Height(listHeight(len(options), formChrome)).
+				Value(&selected),
+		),
+	)
+
+	err = form.Run()
+	if err != nil || len(selected) == 0 {
+		exitCancelled(opts)
+	}
+
+	if err := deleteBranches(selected, opts); err != nil {
+		exitWithStatus(err)
+	}
+}
+
+// interactiveRename lets the user pick a local branch and type a new name
+// for it.
+func interactiveRename(ctx context.Context, opts options) {
+	branches, err := fetchLocalBranches(ctx, opts)
+
+	if err != nil {
+		exitWithStatus(err)
+	}
+
+	current, _ := getCurrentBranch()
+	if opts.noCurrent {
+		branches, current = withoutCurrent(branches, current)
+	}
+
+	if len(branches) == 0 {
+		exitNoBranches(opts, noBranchesMessage(opts, text.NoLocalBranches))
+	}
+
+	if err := formatLabels(ctx, opts, branches); err != nil {
+		exitWithStatus(err)
+	}
+
+	var selected string
+	err = runBranchForm(text.SelectRename, localOptions(branches, current, opts), &selected, opts)
+	if err != nil {
+		exitCancelled(opts)
+	}
+
+	name := selected
+	err = huh.NewInput().
+		Title(fmt.Sprintf(text.RenameBranch, selected)).
+		Value(&name).
+		Validate(validBranchName).
+		Run()
+	if err != nil {
+		exitCancelled(opts)
+	}
+
+	// git renames the current branch when given only the new name
+	args := []string{"branch", "-m", selected, name}
+	if selected == current {
+		args = []string{"branch", "-m", name}
+	}
+	if err := runGit(opts, args...); err != nil {
+		exitWithStatus(err)
+	}
+	if opts.dryRun {
+		return
+	}
+	invalidateCache()
+	notice(opts, fmt.Sprintf("Renamed %s to %s.", selected, name))
+}
+
+// validBranchName checks that name can be given to a new branch.
+func validBranchName(name string) error {
+	if err := checkBranchName(name); err != nil {
+		return err
+	}
+	if localBranchExists(name) {
+		return fmt.Errorf("branch '%s' already exists", name)
+	}
+	return nil
+}
+
+func fetchLocalBranches(ctx context.Context, opts options) ([]branch, error) {
+	var branches []branch
+	var fetchErr error
+
+	withSpinner(opts, "Fetching local branches...", func() {
+		branches, fetchErr = getLocalBranches(ctx, opts.sort)
+		if fetchErr == nil {
+			branches, fetchErr = filterMerged(ctx, branches, opts)
+		}
+		if fetchErr == nil {
+			branches, fetchErr = filterTracked(ctx, branches, opts)
+		}
+		if fetchErr == nil {
+			branches, fetchErr = filterAuthor(ctx, branches, opts)
+		}
+		annotateWorktrees(branches)
+		annotateDescriptions(branches)
+		if opts.prStatus {
+			annotatePullRequests(ctx, branches)
+		}
+	})
+
+	return filterBranches(branches, opts), checkTimeout(ctx, fetchErr, "fetching branches")
+}
+
+func fetchRemoteBranches(ctx context.Context, opts options) ([]branch, error) {
+	if opts.fetch {
+		if err := updateRemotes(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	var branches []branch
+	var fetchErr error
+
+	withSpinner(opts, "Fetching remote branches...", func() {
+		branches, fetchErr = getRemoteBranches(ctx, opts.sort)
+		if fetchErr == nil {
+			branches, fetchErr = filterMerged(ctx, branches, opts)
+		}
+		if fetchErr == nil {
+			branches, fetchErr = filterAuthor(ctx, branches, opts)
+		}
+	})
+
+	return filterBranches(branches, opts), checkTimeout(ctx, fetchErr, "fetching remote branches")
+}
+
+func fetchAllBranches(ctx context.Context, opts options) ([]branch, []branch, error) {
+	if opts.fetch {
+		if err := updateRemotes(ctx, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var localBranches, remoteBranches []branch
+	var fetchErr error
+
+	withSpinner(opts, "Fetching branches...", func() {
+		localBranches, fetchErr = getLocalBranches(ctx, opts.sort)
+		if fetchErr != nil {
+			return
+		}
+		remoteBranches, fetchErr = getRemoteBranches(ctx, opts.sort)
+		if fetchErr != nil {
+			return
+		}
+		localBranches, fetchErr = filterMerged(ctx, localBranches, opts)
+		if fetchErr != nil {
+			return
+		}
+		localBranches, fetchErr = filterTracked(ctx, localBranches, opts)
+		if fetchErr != nil {
+			return
+		}
+		remoteBranches, fetchErr = filterMerged(ctx, remoteBranches, opts)
+		if fetchErr != nil {
+			return
+		}
+		localBranches, fetchErr = filterAuthor(ctx, localBranches, opts)
+		if fetchErr != nil {
+			return
+		}
+		remoteBranches, fetchErr = filterAuthor(ctx, remoteBranches, opts)
+		annotateWorktrees(localBranches)
+		annotateDescriptions(localBranches)
+		if opts.prStatus {
+			annotatePullRequests(ctx, localBranches)
+		}
+	})
+
+	return filterBranches(localBranches, opts), filterBranches(remoteBranches, opts), checkTimeout(ctx, fetchErr, "fetching branches")
+}
+
+func fetchTags(ctx context.Context, opts options) ([]branch, error) {
+	var tags []branch
+	var fetchErr error
+
+	withSpinner(opts, "Fetching tags...", func() {
+		tags, fetchErr = getTags(ctx, opts.sort)
+	})
+
+	return filterBranches(tags, opts), checkTimeout(ctx, fetchErr, "fetching tags")
+}
+
+// updateRemotes runs git fetch for every remote, pruning remote branches that
+// have been deleted upstream.
+func updateRemotes(ctx context.Context, opts options) error {
+	var output []byte
+	var fetchErr error
+
+	withSpinner(opts, "Fetching from remotes...", func() {
+		cmd := gitCommandContext(ctx, "fetch", "--all", "--prune", "--quiet")
+		output, fetchErr = cmd.CombinedOutput()
+	})
+
+	if fetchErr != nil {
+		os.Stderr.Write(output)
+		return checkTimeout(ctx, fetchErr, "fetching from remotes")
+	}
+	invalidateCache()
+	return nil
+}
+
+// withSpinner runs action behind a spinner titled title. The spinner is left
+// out with --quiet or --no-spinner, and where it would only garble the
+// output: in CI and when stderr is not a terminal.
+func withSpinner(opts options, title string, action func()) {
+	if opts.quiet || opts.noSpinner || debugMode || os.Getenv("CI") != "" || !term.IsTerminal(int(os.Stderr.Fd())) {
+		action()
+		return
+	}
+	// stderr keeps stdout clean for --print and --json
+	err := spinner.New().Title(title).Output(os.Stderr).Action(action).Run()
+	// The spinner restores the cursor before giving up on ctrl+c, but the
+	// action may still be running
+	if errors.Is(err, tea.ErrInterrupted) {
+		os.Exit(exitCodeCancelled)
+	}
+}
+
+// notice prints an informational message to stderr unless --quiet is set.
+func notice(opts options, message string) {
+	if opts.quiet {
+		return
+	}
+	fmt.Fprintln(os.Stderr, grayStyle.Render(message))
+}
+
+// limitBranches keeps the first limit branches besides current, which is
+// always kept, and returns how many were left out. A limit of 0 keeps all.
+func limitBranches(branches []branch, current string, limit int) ([]branch, int) {
+	if limit <= 0 {
+		return branches, 0
+	}
+
+	var kept []branch
+	others := 0
+	for _, branch := range branches {
+		if branch.name == current {
+			kept = append(kept, branch)
+		} else if others < limit {
+			kept = append(kept, branch)
+			others++
+		}
+	}
+	return kept, len(branches) - len(kept)
+}
+
+// filterBranches keeps the branches matching --pattern, within --scope and
+// matching none of the --exclude globs. Remote branches match with or
+// without their remote, so "feature/*" finds origin/feature/x.
+func filterBranches(branches []branch, opts options) []branch {
+	if opts.pattern == "" && opts.scope == "" && len(opts.exclude) == 0 {
+		return branches
+	}
+
+	var matched []branch
+	for _, branch := range branches {
+		if opts.pattern != "" && !matchBranch(opts.pattern, branch) {
+			continue
+		}
+		if opts.scope != "" && !inScope(opts.scope, branch) {
+			continue
+		}
+		if slices.ContainsFunc(opts.exclude, func(pattern string) bool {
+			return matchBranch(pattern, branch)
+		}) {
+			continue
+		}
+		matched = append(matched, branch)
+	}
+	return matched
+}
+
+func matchBranch(pattern string, b branch) bool {
+	if ok, _ := path.Match(pattern, b.name); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, b.localName())
+	return ok
+}
+
+// filterMerged keeps the branches merged into --merged and not merged into
+// --no-merged, as git branch does.
+func filterMerged(ctx context.Context, branches []branch, opts options) ([]branch, error) {
+	if opts.merged == "" && opts.noMerged == "" {
+		return branches, nil
+	}
+
+	args := []string{"for-each-ref", "--format=%(refname:short)"}
+	if opts.merged != "" {
+		args = append(args, "--merged="+opts.merged)
+	}
+	if opts.noMerged != "" {
+		args = append(args, "--no-merged="+opts.noMerged)
+	}
+	output, err := gitOutput(ctx, append(args, "refs/heads", "refs/remotes")...)
+	if err != nil {
+		return nil, err
 	}
 
-	interactiveSwitchLocal(ctx)
+	names := strings.Fields(string(output))
+	return slices.DeleteFunc(branches, func(b branch) bool {
+		return !slices.Contains(names, b.name)
+	}), nil
 }
 
-func getCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+// filterTracked keeps the local branches with an upstream for --tracked, or
+// those without one for --untracked.
+func filterTracked(ctx context.Context, branches []branch, opts options) ([]branch, error) {
+	if !opts.tracked && !opts.untracked {
+		return branches, nil
 	}
-	return strings.TrimSpace(string(output)), nil
-}
 
-func getLocalBranches(ctx context.Context) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(refname:short)", "refs/heads")
-	cmd.Stderr = os.Stderr
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, "for-each-ref", "--format=%(refname:short) %(upstream)", "refs/heads")
 	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			os.Stderr.Write(exitErr.Stderr)
-		}
 		return nil, err
 	}
 
-	var branches []string
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
+	// Branch names can't contain spaces
+	var tracked []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if name, upstream, _ := strings.Cut(line, " "); upstream != "" {
+			tracked = append(tracked, name)
 		}
-		branches = append(branches, line)
 	}
+	return slices.DeleteFunc(branches, func(b branch) bool {
+		return slices.Contains(tracked, b.name) != opts.tracked
+	}), nil
+}
 
-	slices.Sort(branches)
+// noBranchesMessage explains an empty branch list, blaming --pattern,
+// --scope or --author if set.
+func noBranchesMessage(opts options, fallback string) string {
+	if opts.pattern != "" {
+		return fmt.Sprintf("No branches matching '%s'.", opts.pattern)
+	}
+	if opts.scope != "" {
+		return fmt.Sprintf("No branches in scope '%s'. Pass --scope= to list them all.", opts.scope)
+	}
+	if opts.author != "" {
+		return fmt.Sprintf("No branches whose last commit is by %s.", opts.author)
+	}
+	return fallback
+}
 
-	return branches, nil
+func switchBranch(branch string, opts options) error {
+	opts = reviewChanges(opts)
+	confirmDirty(opts)
+	previous, _ := getCurrentBranch()
+	if err := runSwitch(branch, opts); err != nil {
+		return err
+	}
+	afterSwitch(previous, opts)
+	return nil
 }
 
-func getRemoteBranches(ctx context.Context) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(refname:short)", "refs/remotes")
-	cmd.Stderr = os.Stderr
-	output, err := cmd.Output()
-	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			os.Stderr.Write(exitErr.Stderr)
-		}
-		return nil, err
+// afterSwitch records a successful switch away from previous in the history,
+// drops the now stale branch cache and confirms the switch on the terminal.
+// Nothing happens if HEAD didn't move.
+func afterSwitch(previous string, opts options) {
+	current, err := getCurrentBranch()
+	if err != nil || current == previous {
+		return
 	}
 
-	var branches []string
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		// Skip entries without '/' (e.g., "origin" from symbolic refs)
-		if !strings.Contains(line, "/") {
-			continue
-		}
-		// Skip HEAD references like "origin/HEAD"
-		if strings.HasSuffix(line, "/HEAD") {
-			continue
+	invalidateCache()
+
+	if !opts.noHistory {
+		recordHistory(current)
+	}
+
+	if !opts.quiet && term.IsTerminal(int(os.Stdout.Fd())) {
+		message := "Switched to " + current
+		if previous != "" {
+			message += " (was " + previous + ")"
 		}
-		branches = append(branches, line)
+		fmt.Println(grayStyle.Render(message))
 	}
+}
 
-	slices.Sort(branches)
+// switchStdin switches to the branch named on stdin, for branch names
+// coming from another program.
+func switchStdin(opts options) error {
+	if stdinIsTerminal() {
+		return errors.New("--stdin expects a branch name piped in, e.g. echo main | gh sw --stdin")
+	}
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
 
-	return branches, nil
+	branch := strings.TrimSpace(string(input))
+	if branch == "" {
+		return errors.New("no branch name on stdin")
+	}
+	if strings.Contains(branch, "\n") {
+		return fmt.Errorf("expected a single branch name on stdin, got %d lines", strings.Count(branch, "\n")+1)
+	}
+	return switchBranch(branch, opts)
 }
 
-func interactiveSwitchLocal(ctx context.Context) {
-	branches, err := fetchLocalBranches(ctx)
-
+// switchDefault switches to the default branch of the repository.
+func switchDefault(ctx context.Context, opts options) error {
+	branch, err := defaultBranch()
 	if err != nil {
-		exitWithStatus(err)
+		return err
 	}
+	notice(opts, fmt.Sprintf("Default branch: %s", branch))
+	return switchNamedBranch(ctx, branch, opts)
+}
 
-	if len(branches) == 0 {
-		fmt.Fprintln(os.Stderr, grayStyle.Render("No local branches found."))
-		return
+// switchUpstream switches to the local branch of what the current branch
+// tracks, creating it to track the remote branch if needed.
+func switchUpstream(ctx context.Context, opts options) error {
+	current, err := getCurrentBranch()
+	if err != nil {
+		return err
 	}
+	upstream := getUpstream(current)
+	if upstream == "" {
+		return fmt.Errorf("branch '%s' has no upstream; set one with git branch --set-upstream-to", current)
+	}
+	notice(opts, fmt.Sprintf("Upstream of %s: %s", current, upstream))
 
-	current, _ := getCurrentBranch()
-
-	var options []huh.Option[string]
-	// Add current branch first with * prefix and gray style
-	if current != "" {
-		options = append(options, huh.NewOption(grayStyle.Render("* "+current), current))
+	// A branch can track another local branch too
+	if b, ok := remoteBranch(ctx, upstream); ok {
+		return switchRemoteBranch(b, opts)
 	}
-	// Add other branches
-	for _, branch := range branches {
-		if branch != current {
-			options = append(options, huh.NewOption(branch, branch))
+	return switchBranch(upstream, opts)
+}
+
+// switchLatest switches to the branch, local or remote, with the most recent
+// commit, fetching first with --fetch. Ties go to local branches and then to
+// the first name.
+func switchLatest(ctx context.Context, opts options) error {
+	if opts.fetch {
+		if err := updateRemotes(ctx, opts); err != nil {
+			return err
 		}
 	}
 
-	var selected string
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a branch to switch to:").
-				Options(options...).
-				Value(&selected),
-		),
-	)
-
-	err = form.Run()
+	output, err := gitOutput(ctx, "for-each-ref", "--format=%(refname)", "--sort=refname", "--sort=-committerdate", "refs/heads", "refs/remotes")
 	if err != nil {
-		fmt.Fprintln(os.Stderr, grayStyle.Render("Operation cancelled."))
-		return
+		return err
 	}
 
-	if err := switchBranch(selected); err != nil {
-		exitWithStatus(err)
+	for _, ref := range strings.Fields(string(output)) {
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			notice(opts, fmt.Sprintf("Latest commit is on %s", name))
+			return switchBranch(name, opts)
+		}
+		name := strings.TrimPrefix(ref, "refs/remotes/")
+		// origin/HEAD only points at another remote branch
+		if strings.HasSuffix(name, "/HEAD") {
+			continue
+		}
+		remotes, err := getRemotes(ctx)
+		if err != nil {
+			return err
+		}
+		b := branch{name: name}
+		b.remote, _ = gitbranches.SplitRemote(name, remotes)
+		notice(opts, fmt.Sprintf("Latest commit is on %s", name))
+		return switchRemoteBranch(b, opts)
 	}
+	return errors.New("no branches found")
 }
 
-func interactiveSwitchRemote(ctx context.Context) {
-	branches, err := fetchRemoteBranches(ctx)
+// defaultBranch returns the branch origin/HEAD points at, falling back to
+// main and then master when that isn't set.
+func defaultBranch() (string, error) {
+	output, err := gitCommand("symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD").Output()
+	if err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if branchExists(branch) {
+			return branch, nil
+		}
+	}
+	return "", errors.New("no default branch found: origin/HEAD is not set and there is no main or master branch")
+}
 
-	if err != nil {
-		exitWithStatus(err)
+// switchPrevious switches to the branch checked out n switches ago.
+func switchPrevious(n int, opts options) error {
+	output, err := gitCommand("rev-parse", "--symbolic-full-name", fmt.Sprintf("@{-%d}", n)).Output()
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(output)), "refs/heads/")
+	if err != nil || !ok {
+		return fmt.Errorf("no previous branch to switch back to (@{-%d})", n)
 	}
+	return switchBranch(branch, opts)
+}
 
-	if len(branches) == 0 {
-		fmt.Fprintln(os.Stderr, grayStyle.Render("No remote branches found."))
-		return
+// switchNamedBranch switches to a branch given on the command line. A name
+// that only exists on a remote is tracked explicitly once confirmed, instead
+// of leaving git to guess, and a remote branch such as origin/feature/x is
+// handled as if selected with -r.
+func switchNamedBranch(ctx context.Context, branch string, opts options) error {
+	if branch == "-" || strings.HasPrefix(branch, "@{") || localBranchExists(branch) {
+		return switchBranch(branch, opts)
 	}
 
-	current, _ := getCurrentBranch()
+	if b, ok := remoteBranch(ctx, branch); ok {
+		return switchRemoteBranch(b, opts)
+	}
 
-	var options []huh.Option[string]
-	// Add current local branch first with * prefix and gray style
-	if current != "" {
-		options = append(options, huh.NewOption(grayStyle.Render("* "+current), current))
+	refs, err := remoteBranchesNamed(branch)
+	if err != nil || len(refs) == 0 {
+		return switchPartialBranch(ctx, branch, opts)
 	}
-	// Add remote branches
-	for _, branch := range branches {
-		options = append(options, huh.NewOption(branch, branch))
+	if len(refs) > 1 {
+		return fmt.Errorf("branch '%s' exists on several remotes (%s); use gh sw -a to pick one", branch, strings.Join(refs, ", "))
 	}
 
-	var selected string
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a remote branch to switch to:").
-				Options(options...).
-				Value(&selected),
-		),
-	)
+	if stdinIsTerminal() &&
+		!confirm(fmt.Sprintf(text.ConfirmTrack, branch, refs[0]), true, opts.yes) {
+		exitCancelled(opts)
+	}
 
-	err = form.Run()
+	previous, _ := getCurrentBranch()
+	if err := trackBranch(branch, refs[0], opts); err != nil {
+		return err
+	}
+	afterSwitch(previous, opts)
+	return nil
+}
+
+// switchPartialBranch switches to the one local branch matching partial,
+// or lets the user pick among several. With no match, a commit is checked
+// out on a detached HEAD, and anything else is passed on as is.
+func switchPartialBranch(ctx context.Context, partial string, opts options) error {
+	branches, err := getLocalBranches(ctx, opts.sort)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, grayStyle.Render("Operation cancelled."))
-		return
+		return switchBranch(partial, opts)
 	}
+	matches := matchPartial(filterBranches(branches, opts), partial)
 
-	// Strip remote prefix: origin/main -> main, origin/feature/auth -> feature/auth
-	if idx := strings.Index(selected, "/"); idx != -1 {
-		selected = selected[idx+1:]
+	switch {
+	case len(matches) == 1:
+		return switchBranch(matches[0].name, opts)
+	case len(matches) > 1 && stdinIsTerminal():
+		selectRef(ctx, scopeLocal, refList{local: matches}, opts)
+		return nil
+	case len(matches) > 1:
+		var names []string
+		for _, branch := range matches {
+			names = append(names, branch.name)
+		}
+		return fmt.Errorf("'%s' matches several branches: %s", partial, strings.Join(names, ", "))
 	}
 
-	if err := switchBranch(selected); err != nil {
-		exitWithStatus(err)
+	if isCommit(partial) {
+		return detachAtCommit(partial, opts)
 	}
+	return switchBranch(partial, opts)
 }
 
-func interactiveSwitchAll(ctx context.Context) {
-	localBranches, remoteBranches, err := fetchAllBranches(ctx)
+// isCommit reports whether rev names a commit, e.g. a SHA or a tag.
+func isCommit(rev string) bool {
+	return gitCommand("rev-parse", "--verify", "--quiet", rev+"^{commit}").Run() == nil
+}
 
-	if err != nil {
-		exitWithStatus(err)
+// detachAtCommit checks out commit on a detached HEAD, asking first since
+// it's easy to mistake for a branch switch.
+func detachAtCommit(commit string, opts options) error {
+	if stdinIsTerminal() && !confirm(fmt.Sprintf(text.ConfirmDetach, commit), false, opts.yes) {
+		exitCancelled(opts)
 	}
 
-	if len(localBranches) == 0 && len(remoteBranches) == 0 {
-		fmt.Fprintln(os.Stderr, grayStyle.Render("No branches found."))
-		return
+	if err := detachHead(commit, opts); err != nil {
+		return err
+	}
+	if !opts.dryRun {
+		notice(opts, "HEAD is now detached. Run `gh sw -` to switch back.")
 	}
+	return nil
+}
 
-	current, _ := getCurrentBranch()
+// matchPartial returns the branches named partial regardless of case, as
+// case-insensitive filesystems treat them, or else those containing it.
+func matchPartial(branches []branch, partial string) []branch {
+	var folded, containing []branch
+	for _, branch := range branches {
+		if strings.EqualFold(branch.name, partial) {
+			folded = append(folded, branch)
+		} else if strings.Contains(strings.ToLower(branch.name), strings.ToLower(partial)) {
+			containing = append(containing, branch)
+		}
+	}
+	if len(folded) > 0 {
+		return folded
+	}
+	return containing
+}
 
-	var options []huh.Option[string]
-	// Add current branch first with * prefix and gray style
-	if current != "" {
-		options = append(options, huh.NewOption(grayStyle.Render("* "+current), current))
+// remoteBranchesNamed lists the remote branches called branch once their
+// remote is stripped, e.g. origin/branch.
+func remoteBranchesNamed(branch string) ([]string, error) {
+	output, err := gitCommand("for-each-ref", "--format=%(refname:short)", "refs/remotes/*/"+branch).Output()
+	if err != nil {
+		return nil, err
 	}
-	// Add local branches
-	for _, branch := range localBranches {
-		if branch != current {
-			options = append(options, huh.NewOption(branch, branch))
+	return strings.Fields(string(output)), nil
+}
+
+func runSwitch(branch string, opts options) error {
+	// With --yes a missing branch is created even without a terminal
+	if !branchExists(branch) && (opts.yes || stdinIsTerminal()) {
+		if !confirm(fmt.Sprintf(text.ConfirmCreate, branch), false, opts.yes) {
+			exitCancelled(opts)
 		}
+		// switchBranch confirms the switch
+		return runCreate("-c", branch, opts)
 	}
-	// Add remote branches
-	for _, branch := range remoteBranches {
-		options = append(options, huh.NewOption(branch, branch))
+
+	// git's own error for this doesn't say what to do about it
+	if path, ok := otherWorktrees()[branch]; ok {
+		return fmt.Errorf("branch '%s' is checked out in another worktree; run `cd %s` to work on it there", branch, path)
 	}
 
-	var selected string
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a branch to switch to:").
-				Options(options...).
-				Value(&selected),
-		),
-	)
+	if opts.stash && isDirty() {
+		return switchWithStash(branch, true, opts)
+	}
 
-	err = form.Run()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, grayStyle.Render("Operation cancelled."))
-		return
+	args := switchArgs(opts, branch)
+	if opts.dryRun {
+		return runGit(opts, args...)
 	}
 
-	// Strip remote prefix if remote branch selected: origin/main -> main
-	if strings.Contains(selected, "/") {
-		if idx := strings.Index(selected, "/"); idx != -1 {
-			selected = selected[idx+1:]
+	var stderr bytes.Buffer
+	cmd := gitCommand(args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	err := cmd.Run()
+	err = newGitError(args, stderr.String(), err)
+	if err != nil && strings.Contains(stderr.String(), "would be overwritten") && (opts.yes || stdinIsTerminal()) {
+		if confirm(text.ConfirmStash, false, opts.yes) {
+			return switchWithStash(branch, false, opts)
 		}
 	}
+	return err
+}
 
-	if err := switchBranch(selected); err != nil {
-		exitWithStatus(err)
+// switchWithStash stashes local changes around a switch. With pop set the
+// changes are re-applied on the new branch, otherwise the user is asked.
+func switchWithStash(branch string, pop bool, opts options) error {
+	before := stashTop()
+	if err := runGit(opts, "stash", "push"); err != nil {
+		return err
 	}
-}
+	// Untracked files alone are dirty but not stashed, and popping then
+	// would apply an older, unrelated stash
+	stashed := opts.dryRun || stashTop() != before
 
-func fetchLocalBranches(ctx context.Context) ([]string, error) {
-	var branches []string
-	var fetchErr error
+	if err := runGit(opts, switchCommand(opts, branch)...); err != nil {
+		if stashed {
+			// Put the changes back where they came from
+			_ = runGit(opts, "stash", "pop")
+		}
+		return err
+	}
+	if !stashed {
+		return nil
+	}
 
-	_ = spinner.New().
-		Title("Fetching local branches...").
-		Action(func() {
-			branches, fetchErr = getLocalBranches(ctx)
-		}).
-		Run()
+	if !pop && !opts.dryRun && (opts.yes || stdinIsTerminal()) {
+		pop = confirm(fmt.Sprintf(text.ConfirmPop, branch), false, opts.yes)
+	}
+	if !pop {
+		notice(opts, text.Stashed)
+		return nil
+	}
+	return runGit(opts, "stash", "pop")
+}
 
-	return branches, fetchErr
+// stashTop returns the commit of the latest stash, or "" if there is none.
+func stashTop() string {
+	output, err := gitCommand("rev-parse", "--verify", "--quiet", "refs/stash").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
 }
 
-func fetchRemoteBranches(ctx context.Context) ([]string, error) {
-	var branches []string
-	var fetchErr error
+// switchArgs builds a git switch command line with args, which throws away
+// local changes with --force-switch.
+func switchArgs(opts options, args ...string) []string {
+	if opts.forceSwitch {
+		return switchCommand(opts, append([]string{"--discard-changes"}, args...)...)
+	}
+	return switchCommand(opts, args...)
+}
 
-	_ = spinner.New().
-		Title("Fetching remote branches...").
-		Action(func() {
-			branches, fetchErr = getRemoteBranches(ctx)
-		}).
+// confirm asks title as a yes or no question, starting on initial. With
+// assumeYes, as given by --yes, it answers yes without asking. Cancelling
+// the prompt answers no.
+func confirm(title string, initial, assumeYes bool) bool {
+	if assumeYes {
+		return true
+	}
+	answer := initial
+	err := huh.NewConfirm().
+		Title(title).
+		Value(&answer).
 		Run()
+	return err == nil && answer
+}
 
-	return branches, fetchErr
+// confirmDiscard makes sure local changes may be thrown away by
+// --force-switch. --yes or the lack of a terminal to ask on count as consent.
+func confirmDiscard(opts options) {
+	if opts.dryRun || !stdinIsTerminal() || !isDirty() {
+		return
+	}
+	if !confirm(text.ConfirmDiscard, false, opts.yes) {
+		exitCancelled(opts)
+	}
 }
 
-func fetchAllBranches(ctx context.Context) ([]string, []string, error) {
-	var localBranches, remoteBranches []string
-	var fetchErr error
+// confirmDirty asks before switching away from uncommitted changes when
+// --confirm-dirty is given, and before discarding them with --force-switch.
+// Without a terminal to ask on it carries on.
+func confirmDirty(opts options) {
+	if opts.forceSwitch {
+		confirmDiscard(opts)
+		return
+	}
+	if !opts.confirmDirty || opts.stash || !stdinIsTerminal() || !isDirty() {
+		return
+	}
+	if !confirm(text.ConfirmDirty, false, opts.yes) {
+		exitCancelled(opts)
+	}
+}
 
-	_ = spinner.New().
-		Title("Fetching branches...").
-		Action(func() {
-			localBranches, fetchErr = getLocalBranches(ctx)
-			if fetchErr != nil {
-				return
-			}
-			remoteBranches, fetchErr = getRemoteBranches(ctx)
-		}).
-		Run()
+// isDirty reports whether the working tree has uncommitted changes.
+func isDirty() bool {
+	output, err := gitCommand("status", "--porcelain").Output()
+	return err == nil && strings.TrimSpace(string(output)) != ""
+}
 
-	return localBranches, remoteBranches, fetchErr
+// runGit runs a git command attached to the terminal's output.
+func runGit(opts options, args ...string) error {
+	return runCommand(opts, gitPath, repoArgs(args...)...)
 }
 
-func switchBranch(branch string) error {
-	cmd := exec.Command("git", "switch", branch)
+// runCommand runs a command attached to the terminal's output. With
+// --dry-run the command is printed to stdout instead.
+func runCommand(opts options, name string, args ...string) error {
+	if opts.dryRun {
+		fmt.Println(strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+	cmd := &command{exec.Command(name, args...)}
+	cmd.Dir = repoDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func createBranch(branch string) error {
-	cmd := exec.Command("git", "switch", "-c", branch)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// branchExists reports whether git switch can resolve branch, either as a
+// local branch or as a remote branch it would create a tracking branch for.
+func branchExists(branch string) bool {
+	// "-" and "@{-N}" refer to previously checked out branches
+	if branch == "-" || strings.HasPrefix(branch, "@{") {
+		return true
+	}
+
+	if localBranchExists(branch) {
+		return true
+	}
+
+	output, err := gitCommand("for-each-ref", "--format=%(refname)", "refs/remotes/*/"+branch).Output()
+	if err != nil {
+		// Let git switch report whatever is wrong
+		return true
+	}
+	return strings.TrimSpace(string(output)) != ""
 }
 
-func forceCreateBranch(branch string) error {
-	cmd := exec.Command("git", "switch", "-C", branch)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// remoteBranch looks name up as a remote-tracking branch of one of the
+// remotes, e.g. origin/feature/x.
+func remoteBranch(ctx context.Context, name string) (branch, bool) {
+	remotes, err := getRemotes(ctx)
+	if err != nil {
+		return branch{}, false
+	}
+	remote, _ := gitbranches.SplitRemote(name, remotes)
+	if !slices.Contains(remotes, remote) ||
+		gitCommand("show-ref", "--verify", "--quiet", "refs/remotes/"+name).Run() != nil {
+		return branch{}, false
+	}
+	return branch{name: name, remote: remote}, true
+}
+
+func localBranchExists(branch string) bool {
+	return gitCommand("show-ref", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// createBranch creates branch at the --from start point, or HEAD, and
+// switches to it.
+func createBranch(branch string, opts options) error {
+	return switchToCreated("-c", branch, opts)
+}
+
+func forceCreateBranch(branch string, opts options) error {
+	return switchToCreated("-C", branch, opts)
+}
+
+// switchToCreated creates branch with runCreate and then records and
+// confirms the switch, as switchBranch does for existing branches.
+func switchToCreated(flag, branch string, opts options) error {
+	previous, _ := getCurrentBranch()
+	if err := runCreate(flag, branch, opts); err != nil {
+		return err
+	}
+	afterSwitch(previous, opts)
+	return nil
 }
 
-func detachHead(startPoint string) error {
-	args := []string{"switch", "--detach"}
+func runCreate(flag, branch string, opts options) error {
+	if err := checkBranchName(branch); err != nil {
+		return err
+	}
+	if err := validStartPoint(opts.from); err != nil {
+		return err
+	}
+	args := []string{flag, branch}
+	if opts.from != "" {
+		args = append(args, opts.from)
+	}
+	if err := runGit(opts, switchCommand(opts, args...)...); err != nil {
+		return err
+	}
+	invalidateCache()
+	return nil
+}
+
+// validStartPoint checks that a branch can be started at ref. An empty ref
+// stands for HEAD.
+func validStartPoint(ref string) error {
+	if ref != "" && !isCommit(ref) {
+		return fmt.Errorf("invalid start point: '%s' is not a commit", ref)
+	}
+	return nil
+}
+
+func detachHead(startPoint string, opts options) error {
+	args := []string{"--detach"}
 	if startPoint != "" {
 		args = append(args, startPoint)
 	}
-	cmd := exec.Command("git", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return runGit(opts, switchCommand(opts, args...)...)
+}
+
+// trackBranch creates branch from the remote branch upstream and switches
+// to it, with tracking set up unless --no-track is given.
+func trackBranch(branch, upstream string, opts options) error {
+	confirmDirty(opts)
+	if opts.noTrack {
+		return runGit(opts, switchArgs(opts, "--quiet", "--no-track", "-c", branch, upstream)...)
+	}
+
+	if err := runGit(opts, switchArgs(opts, "--quiet", "-c", branch, "--track", upstream)...); err != nil {
+		return err
+	}
+	if !opts.dryRun {
+		notice(opts, fmt.Sprintf("Branch '%s' now tracks '%s'.", branch, upstream))
+	}
+	return nil
+}
+
+func orphanBranch(branch string, opts options) error {
+	if err := checkBranchName(branch); err != nil {
+		return err
+	}
+	previous, _ := getCurrentBranch()
+	if err := runGit(opts, switchCommand(opts, "--orphan", branch)...); err != nil {
+		return err
+	}
+	invalidateCache()
+	afterSwitch(previous, opts)
+	return nil
+}
+
+// deleteBranches deletes each branch in turn, carrying on past failures so
+// that one unmerged branch doesn't block the rest. The returned error joins
+// every failure.
+func deleteBranches(branches []string, opts options) error {
+	flag := "-d"
+	if opts.force {
+		flag = "-D"
+	}
+
+	var errs []error
+	var failed []string
+	for _, branch := range branches {
+		var err error
+		if opts.force || opts.dryRun {
+			err = runGit(opts, "branch", flag, branch)
+		} else {
+			err = deleteBranch(branch, opts)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			failed = append(failed, branch)
+		}
+	}
+	if opts.dryRun {
+		return nil
+	}
+	invalidateCache()
+
+	summary := fmt.Sprintf("Deleted %d of %d branches.", len(branches)-len(failed), len(branches))
+	if len(failed) > 0 {
+		summary += " Failed: " + strings.Join(failed, ", ")
+	}
+	notice(opts, summary)
+
+	return errors.Join(errs...)
 }
 
-func orphanBranch(branch string) error {
-	cmd := exec.Command("git", "switch", "--orphan", branch)
+// deleteBranch deletes branch with git branch -d. When git refuses as the
+// branch isn't fully merged, it offers to force delete it instead: always
+// with --yes, never with --no-force or without a terminal to ask on.
+func deleteBranch(branch string, opts options) error {
+	var stderr bytes.Buffer
+	args := []string{"branch", "-d", branch}
+	cmd := gitCommand(args...)
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+
+	if notFullyMerged(stderr.String()) && !opts.noForce && (opts.yes || stdinIsTerminal()) &&
+		confirm(fmt.Sprintf(text.ConfirmForceDelete, branch), false, opts.yes) {
+		return runGit(opts, "branch", "-D", branch)
+	}
+	os.Stderr.Write(stderr.Bytes())
+	return newGitError(args, stderr.String(), err)
 }
 
 func exitWithStatus(err error) {
+	if jsonErrors {
+		exitJSON(err, errorCode(err))
+	}
+
+	// ctrl+c reaches git too, which may die of it before we notice
+	if interrupted.Err() != nil || killedByInterrupt(err) {
+		os.Exit(exitCodeCancelled)
+	}
+
+	// Checked before git's errors, as git killed by the deadline is one too
+	var timeoutErr *timeoutError
+	if errors.As(err, &timeoutErr) {
+		fmt.Fprintln(os.Stderr, grayStyle.Render(timeoutErr.Error()))
+		os.Exit(exitCodeTimeout)
+	}
+
+	var conflictErr *conflictError
+	if errors.As(err, &conflictErr) {
+		fmt.Fprintln(os.Stderr, grayStyle.Render(conflictErr.Error()))
+		os.Exit(exitCodeConflict)
+	}
+
+	// git has explained itself on stderr; only add what to do about it
+	var gitErr *gitError
+	if errors.As(err, &gitErr) {
+		if gitErr.notFound() {
+			fmt.Fprintln(os.Stderr, grayStyle.Render("Run `gh sw -f -a` to fetch and list every branch, remote ones included."))
+		}
+		os.Exit(gitErr.exitCode)
+	}
+
 	var exitErr *exec.ExitError
 	if errors.As(err, &exitErr) {
 		os.Exit(exitErr.ExitCode())
 	}
 
+	// git itself could not be run
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		fmt.Fprintln(os.Stderr, grayStyle.Render(fmt.Sprintf(
+			"%s not found. Install git from https://git-scm.com/downloads or fix the \"git\" path in gh-sw/config.json.",
+			execErr.Name)))
+		os.Exit(exitCodeNoGit)
+	}
+
 	// Print message only for non-ExitError
+	if errors.Is(err, errNotGitRepo) {
+		fmt.Fprintln(os.Stderr, grayStyle.Render(err.Error()))
+		os.Exit(exitCodeNotGitRepo)
+	}
 	fmt.Fprintln(os.Stderr, err)
-	os.Exit(1)
+	os.Exit(exitCodeError)
+}
+
+// exitError reports an invalid setting, such as a malformed --timeout.
+func exitError(err error) {
+	if jsonErrors {
+		exitJSON(err, exitCodeError)
+	}
+	fmt.Fprintln(os.Stderr, "error: "+err.Error())
+	os.Exit(exitCodeError)
+}
+
+// killedByInterrupt reports whether err is a command that died of ctrl+c.
+func killedByInterrupt(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGINT
+}
+
+// exitCancelled reports that the user backed out of a prompt.
+func exitCancelled(opts options) {
+	notice(opts, text.Cancelled)
+	os.Exit(exitCodeCancelled)
+}
+
+// exitNoBranches reports that there was nothing to select from.
+func exitNoBranches(opts options, message string) {
+	notice(opts, message)
+	os.Exit(exitCodeNoBranches)
 }
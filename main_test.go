@@ -0,0 +1,547 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// fakeGit points gitPath at a shell script for the duration of the test.
+func fakeGit(t *testing.T, script string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "git")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := gitPath
+	gitPath = path
+	t.Cleanup(func() { gitPath = old })
+}
+
+func TestGetLocalBranches(t *testing.T) {
+	tests := []struct {
+		name    string
+		sortKey string
+		refs    string
+		want    []branch
+	}{
+		{
+			name: "sorted by name",
+			refs: "main\t1700000000\t2 days ago\tahead 1, behind 2\tFix login\\000\n" +
+				"feature/auth\t1700100000\t3 hours ago\t\tAdd auth\twith a tab\\000\n",
+			want: []branch{
+				{name: "feature/auth", committed: time.Unix(1700100000, 0), date: "3 hours ago", subject: "Add auth\twith a tab"},
+				{name: "main", committed: time.Unix(1700000000, 0), date: "2 days ago", subject: "Fix login", ahead: 1, behind: 2},
+			},
+		},
+		{
+			name:    "kept in git's order with a sort key",
+			sortKey: sortRecent,
+			refs:    "main\t\t1 hour ago\t\tNewest\\000\nfeature/auth\t\t3 hours ago\tgone\tOlder\\000\n",
+			want: []branch{
+				{name: "main", date: "1 hour ago", subject: "Newest"},
+				{name: "feature/auth", date: "3 hours ago", subject: "Older"},
+			},
+		},
+		{
+			name: "newlines in names and subjects",
+			refs: "odd\nname\t\t1 hour ago\t\tFirst line\nsecond line\\000\n" +
+				"main\t\t2 hours ago\t\tPlain\\000\n",
+			want: []branch{
+				{name: "main", date: "2 hours ago", subject: "Plain"},
+				{name: "odd\nname", date: "1 hour ago", subject: "First line\nsecond line"},
+			},
+		},
+		{
+			name: "no branches",
+			refs: "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeGit(t, "printf '"+tt.refs+"'\n")
+			got, err := getLocalBranches(context.Background(), tt.sortKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getLocalBranches() = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRemoteBranches(t *testing.T) {
+	tests := []struct {
+		name    string
+		remotes string
+		refs    string
+		want    []string
+	}{
+		{
+			name:    "skips HEAD and bare remote names",
+			remotes: "origin",
+			refs:    "origin\t\t\t\\000\norigin/HEAD\t\t\t\\000\norigin/main\t\t\t\\000\norigin/feature/auth\t\t\t\\000\n",
+			want:    []string{"origin/feature/auth", "origin/main"},
+		},
+		{
+			name:    "several remotes",
+			remotes: "origin\nupstream",
+			refs:    "upstream/main\t\t\t\\000\norigin/main\t\t\t\\000\nupstream/HEAD\t\t\t\\000\n",
+			want:    []string{"origin/main", "upstream/main"},
+		},
+		{
+			name:    "no remotes",
+			remotes: "",
+			refs:    "",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeGit(t, `case "$1" in
+remote) printf '`+tt.remotes+`\n' ;;
+for-each-ref) printf '`+tt.refs+`' ;;
+esac
+`)
+			branches, err := getRemoteBranches(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, b := range branches {
+				got = append(got, b.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getRemoteBranches() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPartial(t *testing.T) {
+	branches := []branch{
+		{name: "main"},
+		{name: "maintenance"},
+		{name: "feature/Auth"},
+		{name: "fix/auth-token"},
+		{name: "Release"},
+		{name: "release"},
+	}
+
+	tests := []struct {
+		partial string
+		want    []string
+	}{
+		// Matches regardless of case win over substrings
+		{"Main", []string{"main"}},
+		{"MAIN", []string{"main"}},
+		{"feature/auth", []string{"feature/Auth"}},
+		// Branches differing only by case are all offered
+		{"RELEASE", []string{"Release", "release"}},
+		{"auth", []string{"feature/Auth", "fix/auth-token"}},
+		{"token", []string{"fix/auth-token"}},
+		{"missing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.partial, func(t *testing.T) {
+			var got []string
+			for _, b := range matchPartial(branches, tt.partial) {
+				got = append(got, b.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchPartial(%q) = %q; want %q", tt.partial, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeOptions(t *testing.T) {
+	// Outside a repository there are no pinned or recent branches
+	fakeGit(t, "exit 128\n")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	refs := refList{
+		local: []branch{{name: "main"}, {name: "dev"}},
+		remote: []branch{
+			{name: "origin/main", remote: "origin"},
+			{name: "upstream/dev", remote: "upstream"},
+			{name: "origin/dev", remote: "origin"},
+		},
+		tags: []branch{{name: "v1", tag: true}},
+	}
+
+	tests := []struct {
+		name  string
+		scope scope
+		refs  refList
+		limit int
+		noPin bool
+		want  []string
+	}{
+		{
+			name:  "local",
+			scope: scopeLocal,
+			refs:  refList{local: refs.local},
+			want:  []string{"main", "dev"},
+		},
+		{
+			name:  "local without pinning the current branch",
+			scope: scopeLocal,
+			refs:  refList{local: []branch{{name: "dev"}, {name: "main"}}},
+			noPin: true,
+			want:  []string{"dev", "main"},
+		},
+		{
+			name:  "remote keeps git's order",
+			scope: scopeRemote,
+			refs:  refList{remote: refs.remote},
+			want:  []string{"main", "origin/main", "upstream/dev", "origin/dev"},
+		},
+		{
+			name:  "remote with a limit",
+			scope: scopeRemote,
+			refs:  refList{remote: refs.remote},
+			limit: 1,
+			want:  []string{"main", "origin/main", ""},
+		},
+		{
+			name:  "tags",
+			scope: scopeTags,
+			refs:  refList{tags: refs.tags},
+			want:  []string{"main", "refs/tags/v1"},
+		},
+		{
+			name:  "all groups remotes and tags under headers",
+			scope: scopeAll,
+			refs:  refs,
+			want: []string{
+				"main", "dev",
+				"", "origin/main", "origin/dev",
+				"", "upstream/dev",
+				"", "refs/tags/v1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, option := range scopeOptions(tt.scope, tt.refs, "main", options{limit: tt.limit, noPinCurrent: tt.noPin}) {
+				got = append(got, option.Value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scopeOptions() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSelection(t *testing.T) {
+	refs := refList{
+		local:  []branch{{name: "main"}},
+		remote: []branch{{name: "origin/dev", remote: "origin"}},
+		tags:   []branch{{name: "v1", tag: true}},
+	}
+
+	tests := []struct {
+		name  string
+		refs  refList
+		value string
+		want  branch
+	}{
+		{"local branch", refList{local: refs.local}, "main", branch{name: "main"}},
+		{"remote branch", refList{remote: refs.remote}, "origin/dev", branch{name: "origin/dev", remote: "origin"}},
+		{"current branch in remote scope", refList{remote: refs.remote}, "main", branch{name: "main"}},
+		{"tag", refList{tags: refs.tags}, "refs/tags/v1", branch{name: "v1", tag: true}},
+		{"remote branch in all scope", refs, "origin/dev", branch{name: "origin/dev", remote: "origin"}},
+		{"tag in all scope", refs, "refs/tags/v1", branch{name: "v1", tag: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveSelection(tt.value, tt.refs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveSelection(%q) = %+v; want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		exitCode int
+		notFound bool
+	}{
+		{"unknown ref", "fatal: malformed object name nope", 128, false},
+		{"missing branch", "fatal: invalid reference: nope", 128, true},
+		{"other failure", "error: something else", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeGit(t, "echo '"+tt.stderr+"' >&2\nexit "+strconv.Itoa(tt.exitCode)+"\n")
+			_, err := getLocalBranches(context.Background(), "")
+			var gitErr *gitError
+			if !errors.As(err, &gitErr) {
+				t.Fatalf("getLocalBranches() error = %v; want a *gitError", err)
+			}
+			if strings.TrimSpace(gitErr.stderr) != tt.stderr {
+				t.Errorf("stderr = %q; want %q", gitErr.stderr, tt.stderr)
+			}
+			if gitErr.exitCode != tt.exitCode {
+				t.Errorf("exitCode = %d; want %d", gitErr.exitCode, tt.exitCode)
+			}
+			if gitErr.notFound() != tt.notFound {
+				t.Errorf("notFound() = %v; want %v", gitErr.notFound(), tt.notFound)
+			}
+		})
+	}
+}
+
+func TestSwitchSupported(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"git version 2.23.0\n", true},
+		{"git version 2.39.2 (Apple Git-143)\n", true},
+		{"git version 2.45.1.windows.1\n", true},
+		{"git version 3.0.0\n", true},
+		{"git version 2.22.5\n", false},
+		{"git version 1.8.3.1\n", false},
+		{"unexpected\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.version), func(t *testing.T) {
+			if got := switchSupported(tt.version); got != tt.want {
+				t.Errorf("switchSupported(%q) = %v; want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBranchName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"my feature", "'my feature' is not a valid branch name: it contains a space at position 3"},
+		{"feature..x", "'feature..x' is not a valid branch name: it contains '..' at position 8"},
+		{"-feature", "'-feature' is not a valid branch name: it starts with '-'"},
+		{"feature/", "'feature/' is not a valid branch name: it ends with '/'"},
+		{"feature/.x", "'feature/.x' is not a valid branch name: it has a part starting with '.': '.x'"},
+		{"fix:login", "'fix:login' is not a valid branch name: it contains ':' at position 4"},
+		{"feature/x.lock", "'feature/x.lock' is not a valid branch name: it has a part ending in '.lock': 'x.lock'"},
+		{"HEAD", "'HEAD' is not a valid branch name"},
+	}
+
+	// git has the final say; the names above only get their explanation here
+	fakeGit(t, "exit 1\n")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBranchName(tt.name)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("checkBranchName(%q) = %v; want %s", tt.name, err, tt.want)
+			}
+		})
+	}
+
+	fakeGit(t, "exit 0\n")
+	if err := checkBranchName("feature/auth"); err != nil {
+		t.Errorf("checkBranchName(%q) = %v; want nil", "feature/auth", err)
+	}
+}
+
+func TestColumnLayout(t *testing.T) {
+	names := []string{"main", "develop", "feature/a", "fix/b", "release"}
+	tests := []struct {
+		name  string
+		width int
+		want  []string
+	}{
+		{"no terminal", 0, names},
+		{"too narrow", 12, names},
+		{"two columns", 24, []string{
+			"main       fix/b",
+			"develop    release",
+			"feature/a",
+		}},
+		{"three columns", 33, []string{
+			"main       feature/a  release",
+			"develop    fix/b",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := columnLayout(names, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columnLayout(%d) = %q; want %q", tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRememberable(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"-r", "--fetch"}, true},
+		{[]string{"-a", "--sort", "-committerdate", "--exclude", "renovate/*"}, true},
+		{[]string{"--scope=team-a", "-R"}, true},
+		{[]string{"-r", "--print"}, false},
+		{[]string{"--delete"}, false},
+		{[]string{"feature/x"}, false},
+		{[]string{"-p", "feature/*", "main"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			if got := rememberable(tt.args); got != tt.want {
+				t.Errorf("rememberable(%q) = %v; want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	old := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	t.Cleanup(func() { lipgloss.SetColorProfile(old) })
+	start, end, _ := strings.Cut(matchStyle.Render("\x00"), "\x00")
+	if start == "" {
+		t.Fatal("matchStyle renders no styling")
+	}
+	gray := "\x1b[38;5;240m"
+	reset := "\x1b[0m"
+
+	tests := []struct {
+		name  string
+		label string
+		query string
+		want  string
+		ok    bool
+	}{
+		{
+			name:  "plain label",
+			label: "feature/auth",
+			query: "auth",
+			want:  "feature/" + start + "auth" + end,
+			ok:    true,
+		},
+		{
+			name:  "styled current branch",
+			label: "* main " + gray + "Fix login" + reset,
+			query: "main",
+			want:  "* " + start + "main" + end + " " + gray + "Fix login" + reset,
+			ok:    true,
+		},
+		{
+			name:  "across a style boundary",
+			label: "ab" + gray + "cd" + reset,
+			query: "bc",
+			want:  "a" + start + "b" + gray + "c" + end + gray + "d" + reset,
+			ok:    true,
+		},
+		{
+			name:  "repeated matches regardless of case",
+			label: "fix/Fix-login",
+			query: "FIX",
+			want:  start + "fix" + end + "/" + start + "Fix" + end + "-login",
+			ok:    true,
+		},
+		{
+			name:  "no match",
+			label: "main " + gray + "Fix login" + reset,
+			query: "auth",
+			want:  "main " + gray + "Fix login" + reset,
+			ok:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := highlight(tt.label, tt.query)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("highlight(%q, %q) = %q, %v; want %q, %v", tt.label, tt.query, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	t.Run("no styling", func(t *testing.T) {
+		lipgloss.SetColorProfile(termenv.Ascii)
+		got, ok := highlight("feature/auth", "auth")
+		if got != "feature/auth" || !ok {
+			t.Errorf("highlight() = %q, %v; want %q, true", got, ok, "feature/auth")
+		}
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+		wantErr  string
+	}{
+		{
+			template: "{name} ({upstream})",
+			want:     "%(refname:short) (%(upstream:short))",
+		},
+		{
+			template: "{name} 100% {date}",
+			want:     "%(refname:short) 100%% %(committerdate:relative)",
+		},
+		{
+			template: "{name} {email}",
+			wantErr:  "unknown placeholder in format: {email} (expected {author}, {date}, {hash}, {name}, {subject}, {track}, {upstream})",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := parseFormat(tt.template)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseFormat(%q) error = %v; want %s", tt.template, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseFormat(%q) = %q, %v; want %q", tt.template, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	// A subject can't span lines, but a template can
+	fakeGit(t, `printf 'refs/heads/main\tmain\nFix login\000\nrefs/remotes/origin/feature/x\torigin/feature/x\twith a tab\000\n'`)
+
+	local := []branch{{name: "main"}, {name: "gone"}}
+	remote := []branch{{name: "origin/feature/x", remote: "origin"}}
+	if err := formatLabels(context.Background(), options{format: "x"}, local, remote); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"main\nFix login", "", "origin/feature/x\twith a tab"}
+	got := []string{local[0].label, local[1].label, remote[0].label}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("formatLabels() labels = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// uiText holds the titles and messages shown by the interactive UI. Any of
+// them can be overridden in gh-sw/messages.json under the user config
+// directory, keyed by the JSON names below. Each %s is filled in with a name,
+// in order.
+type uiText struct {
+	SelectBranch       string `json:"select_branch"`
+	SelectRemoteBranch string `json:"select_remote_branch"`
+	SelectRemote       string `json:"select_remote"`
+	SelectTag          string `json:"select_tag"`
+	SelectPullRequest  string `json:"select_pull_request"`
+	SelectDelete       string `json:"select_delete"`
+	SelectRename       string `json:"select_rename"`
+	RenameBranch       string `json:"rename_branch"`
+	SelectVisited      string `json:"select_visited"`
+	SelectWorktrees    string `json:"select_worktrees"`
+	CreateBranch       string `json:"create_branch"`
+	StartPoint         string `json:"start_point"`
+	StartPointHint     string `json:"start_point_hint"`
+	RecentCommits      string `json:"recent_commits"`
+	ConfirmStash       string `json:"confirm_stash"`
+	ConfirmDirty       string `json:"confirm_dirty"`
+	ConfirmDiscard     string `json:"confirm_discard"`
+	ConfirmCreate      string `json:"confirm_create"`
+	ConfirmTrack       string `json:"confirm_track"`
+	ConfirmDetach      string `json:"confirm_detach"`
+	ConfirmPop         string `json:"confirm_pop"`
+	ConfirmUnmerged    string `json:"confirm_unmerged"`
+	Stashed            string `json:"stashed"`
+	ConfirmWorktree    string `json:"confirm_worktree"`
+	ConfirmReset       string `json:"confirm_reset"`
+	ConfirmForceDelete string `json:"confirm_force_delete"`
+	RebasingOnto       string `json:"rebasing_onto"`
+	ReviewChanges      string `json:"review_changes"`
+	ReviewKeep         string `json:"review_keep"`
+	ReviewStash        string `json:"review_stash"`
+	ReviewCancel       string `json:"review_cancel"`
+	Cancelled          string `json:"cancelled"`
+	DetachedHead       string `json:"detached_head"`
+	NoLocalBranches    string `json:"no_local_branches"`
+	NoRemoteBranches   string `json:"no_remote_branches"`
+	NoBranches         string `json:"no_branches"`
+	NoTags             string `json:"no_tags"`
+	NoBranchesToDelete string `json:"no_branches_to_delete"`
+	NoPullRequests     string `json:"no_pull_requests"`
+	NoVisited          string `json:"no_visited"`
+	NoCommits          string `json:"no_commits"`
+	NoWorktreeBranches string `json:"no_worktree_branches"`
+}
+
+var text = uiText{
+	SelectBranch:       "Select a branch to switch to:",
+	SelectRemoteBranch: "Select a remote branch to switch to:",
+	SelectRemote:       "Select a remote:",
+	SelectTag:          "Select a tag to check out:",
+	SelectPullRequest:  "Select a pull request to check out:",
+	SelectDelete:       "Select branches to delete:",
+	SelectRename:       "Select a branch to rename:",
+	RenameBranch:       "New name for '%s':",
+	SelectVisited:      "Select a recently visited branch:",
+	SelectWorktrees:    "Select branches to add worktrees for:",
+	CreateBranch:       "Name of a new branch to create:",
+	StartPoint:         "Start it at:",
+	StartPointHint:     "A branch, tag or commit. Leave empty for HEAD.",
+	RecentCommits:      "Recent commits",
+	ConfirmStash:       "Stash your local changes and switch?",
+	ConfirmDirty:       "You have uncommitted changes. Switch anyway?",
+	ConfirmDiscard:     "Discard your uncommitted changes for good and switch?",
+	ConfirmCreate:      "Branch '%s' does not exist. Create it?",
+	ConfirmTrack:       "Branch '%s' only exists as '%s'. Create a local branch tracking it?",
+	ConfirmDetach:      "'%s' is not a branch. Detach HEAD at this commit?",
+	ConfirmPop:         "Apply the stashed changes on '%s'?",
+	ConfirmUnmerged:    "%s is not fully merged. Delete it anyway? [y/N]",
+	Stashed:            "Your changes were stashed. Run `git stash pop` to restore them.",
+	ConfirmWorktree:    "Branch '%s' does not exist. Create it in a new worktree?",
+	ConfirmReset:       "Reset '%s' to '%s' and lose these changes?",
+	ConfirmForceDelete: "'%s' is not fully merged. Force delete it?",
+	RebasingOnto:       "Rebasing onto %s...",
+	ReviewChanges:      "You have uncommitted changes. What should happen to them?",
+	ReviewKeep:         "Switch and take them along",
+	ReviewStash:        "Stash them and switch without them",
+	ReviewCancel:       "Don't switch",
+	Cancelled:          "Operation cancelled.",
+	DetachedHead:       "(detached HEAD)",
+	NoLocalBranches:    "No local branches found.",
+	NoRemoteBranches:   "No remote branches found.",
+	NoBranches:         "No branches found.",
+	NoTags:             "No tags found.",
+	NoBranchesToDelete: "No branches to delete.",
+	NoPullRequests:     "No open pull requests found.",
+	NoVisited:          "No branch switches found in the reflog.",
+	NoCommits:          "No commits yet, so there are no branches to switch to.",
+	NoWorktreeBranches: "No branches to add a worktree for.",
+}
+
+// loadText applies the overrides from messages.json to text. Strings the
+// file leaves out keep their defaults, and a missing file changes nothing.
+func loadText() error {
+	path, err := configPath("messages.json")
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return nil
+}
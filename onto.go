@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// conflictError is a rebase by --onto that stopped at conflicts, leaving the
+// repository mid-rebase for the user to resolve.
+type conflictError struct {
+	branch string
+	onto   string
+}
+
+func (e *conflictError) Error() string {
+	return fmt.Sprintf("Rebasing '%s' onto '%s' stopped at conflicts. Resolve them and run `git rebase --continue`, or `git rebase --abort` to go back.", e.branch, e.onto)
+}
+
+// rebaseOnto rebases the current branch onto ref for --onto, after switching
+// to branch if one is given. git's output is held back behind the spinner
+// and only shown when the rebase fails.
+func rebaseOnto(ctx context.Context, ref, branch string, opts options) error {
+	if !isCommit(ref) {
+		return fmt.Errorf("'%s' does not exist; --onto needs a branch to rebase onto", ref)
+	}
+	if branch != "" {
+		if err := switchNamedBranch(ctx, branch, opts); err != nil {
+			return err
+		}
+	}
+	current, err := getCurrentBranch()
+	if err != nil {
+		return err
+	}
+	if opts.dryRun {
+		return runGit(opts, "rebase", ref)
+	}
+
+	var output []byte
+	withSpinner(opts, fmt.Sprintf(text.RebasingOnto, ref), func() {
+		// Not bound by --timeout, which is for listing
+		output, err = gitCommandContext(interrupted, "rebase", ref).CombinedOutput()
+	})
+	invalidateCache()
+	if err != nil {
+		os.Stderr.Write(output)
+		if rebaseInProgress() {
+			return &conflictError{branch: current, onto: ref}
+		}
+		return err
+	}
+	notice(opts, fmt.Sprintf("Rebased '%s' onto '%s'.", current, ref))
+	return nil
+}
+
+// rebaseInProgress reports whether a rebase stopped part way, as it does at
+// conflicts.
+func rebaseInProgress() bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		output, err := gitCommand("rev-parse", "--git-path", dir).Output()
+		if err != nil {
+			continue
+		}
+		// Relative paths are relative to where git ran
+		path := strings.TrimSpace(string(output))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoDir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// localPicker wraps the local branch select so that ctrl+d deletes the
+// highlighted branch and refreshes the list in place.
+type localPicker struct {
+	opts     options
+	current  string
+	selected string
+	options  []huh.Option[string]
+	form     *huh.Form
+	filter   *optionFilter
+	status   string
+	// names are the branches the picker started with; deleting one never
+	// brings in others
+	names []string
+	// unmerged is the branch waiting for confirmation to force delete it
+	unmerged string
+	// prs are the pull requests of the branches, fetched once for
+	// --pr-status
+	prs map[string]int
+}
+
+// runLocalPicker lets the user select one of branches and returns its name.
+func runLocalPicker(branches []branch, current string, opts options) (string, error) {
+	m := &localPicker{opts: opts, current: current, prs: map[string]int{}}
+	for _, branch := range branches {
+		m.names = append(m.names, branch.name)
+		m.prs[branch.name] = branch.pr
+	}
+	m.newForm(branches, 0)
+
+	_, err := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithReportFocus()).Run()
+	if errors.Is(err, tea.ErrInterrupted) || m.form.State == huh.StateAborted {
+		return "", huh.ErrUserAborted
+	}
+	if err != nil {
+		return "", err
+	}
+	return m.selected, nil
+}
+
+// newForm builds the select for branches with the cursor on the option at
+// index, or the last one if there are fewer.
+func (m *localPicker) newForm(branches []branch, index int) {
+	m.options = localOptions(branches, m.current, m.opts)
+	m.selected = ""
+	if len(m.options) > 0 {
+		m.selected = m.options[min(index, len(m.options)-1)].Value
+	}
+	query := ""
+	if m.filter != nil {
+		query = m.filter.query
+	}
+	m.form, m.filter = branchForm(text.SelectBranch, m.options, &m.selected, m.opts)
+	// Deleting a branch keeps the list filtered
+	if query != "" {
+		m.filter.query = query
+		m.filter.apply()
+	}
+	m.form.SubmitCmd = tea.Quit
+	m.form.CancelCmd = tea.Interrupt
+}
+
+func (m *localPicker) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m *localPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if m.unmerged != "" {
+			branch := m.unmerged
+			m.unmerged = ""
+			if msg.String() == "y" {
+				return m, m.delete(branch, true)
+			}
+			m.status = fmt.Sprintf("Kept %s.", branch)
+			return m, nil
+		}
+		if msg.String() == "ctrl+d" {
+			return m, m.delete(m.selected, false)
+		}
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.filter.update(key) {
+		msg = filterMsg{}
+	}
+
+	form, cmd := m.form.Update(msg)
+	m.form = form.(*huh.Form)
+	return m, cmd
+}
+
+func (m *localPicker) View() string {
+	if m.form.State != huh.StateNormal {
+		return ""
+	}
+	if m.status == "" {
+		return m.form.View()
+	}
+	return m.form.View() + "\n" + grayStyle.Render(m.status)
+}
+
+// delete deletes the branch name and reloads the list. An unmerged branch
+// is only force deleted once confirmed.
+func (m *localPicker) delete(name string, force bool) tea.Cmd {
+	if name == "" || name == m.current {
+		m.status = "The current branch can't be deleted."
+		return nil
+	}
+
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	if m.opts.dryRun {
+		m.status = strings.Join(append([]string{gitPath}, repoArgs("branch", flag, name)...), " ")
+		return nil
+	}
+
+	output, err := gitCommand("branch", flag, name).CombinedOutput()
+	if err != nil {
+		if !force && !m.opts.noForce && notFullyMerged(string(output)) {
+			m.unmerged = name
+			m.status = fmt.Sprintf(text.ConfirmUnmerged, name)
+			return nil
+		}
+		m.status = strings.TrimSpace(string(output))
+		return nil
+	}
+	m.status = fmt.Sprintf("Deleted %s.", name)
+	invalidateCache()
+
+	// The listing main timed has long run out while the user browsed
+	ctx := interrupted
+	if listTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, listTimeout)
+		defer cancel()
+	}
+	branches, err := getLocalBranches(ctx, m.opts.sort)
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	annotateWorktrees(branches)
+	annotateDescriptions(branches)
+	for i := range branches {
+		branches[i].pr = m.prs[branches[i].name]
+	}
+	_ = formatLabels(ctx, m.opts, branches)
+	// Keep the cursor where it was, now on the next branch
+	index := slices.IndexFunc(m.options, func(o huh.Option[string]) bool {
+		return o.Value == name
+	})
+	var kept []branch
+	for _, b := range branches {
+		if slices.Contains(m.names, b.name) {
+			kept = append(kept, b)
+		}
+	}
+	m.newForm(kept, max(index, 0))
+	return m.form.Init()
+}
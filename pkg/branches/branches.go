@@ -0,0 +1,231 @@
+// Package branches lists the branches of a git repository and lets the user
+// pick one, as gh-sw does.
+//
+// git is run through a Git function, so that callers choose the binary and
+// repository, can cache its output, or fake it in tests:
+//
+//	list, err := branches.ListLocal(ctx, branches.Command("git"), "")
+//	if err != nil {
+//		return err
+//	}
+//	b, err := branches.Pick("Select a branch:", list)
+package branches
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+)
+
+const (
+	branchFormat = "--format=%(refname:short)%09%(committerdate:unix)%09%(committerdate:relative)%09%(upstream:track,nobracket)%09%(contents:subject)%00"
+	tagFormat    = "--format=%(refname:lstrip=2)%09%(creatordate:unix)%09%(creatordate:relative)%09%09%(contents:subject)%00"
+)
+
+// ErrNoBranches is returned by Pick when there is nothing to pick from.
+var ErrNoBranches = errors.New("no branches to pick from")
+
+// Git runs git with args and returns its stdout.
+type Git func(ctx context.Context, args ...string) ([]byte, error)
+
+// Command returns a Git that runs the git binary at path, e.g. "git", in
+// the current directory.
+func Command(path string) Git {
+	return func(ctx context.Context, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, path, args...).Output()
+	}
+}
+
+// Branch is a ref listed by git for-each-ref along with its last commit.
+type Branch struct {
+	// Name is the short name of the ref, e.g. main or origin/main
+	Name string
+	// Remote is the remote of a remote-tracking branch, and empty otherwise
+	Remote string
+	// Committed is when the last commit was made; Date is the same, relative
+	Committed time.Time
+	Date      string
+	// Subject is the first line of the last commit message
+	Subject string
+	// Ahead and Behind count commits relative to the upstream, if any
+	Ahead  int
+	Behind int
+}
+
+// LocalName is the name of the branch without its remote, which is what a
+// local branch created from it is called.
+func (b Branch) LocalName() string {
+	if b.Remote == "" {
+		return b.Name
+	}
+	return strings.TrimPrefix(b.Name, b.Remote+"/")
+}
+
+// ListLocal lists the local branches, sorted by git with sortKey (e.g.
+// "-committerdate") or else by name.
+func ListLocal(ctx context.Context, git Git, sortKey string) ([]Branch, error) {
+	return list(ctx, git, branchFormat, sortKey, "refs/heads")
+}
+
+// ListRemote lists the remote-tracking branches of all remotes, sorted like
+// ListLocal. The remotes' HEAD refs are left out.
+func ListRemote(ctx context.Context, git Git, sortKey string) ([]Branch, error) {
+	refs, err := list(ctx, git, branchFormat, sortKey, "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := git(ctx, "remote")
+	if err != nil {
+		return nil, err
+	}
+	remotes := strings.Fields(string(output))
+
+	var branches []Branch
+	for _, b := range refs {
+		// Skip entries without '/' (e.g., "origin" from symbolic refs)
+		if !strings.Contains(b.Name, "/") {
+			continue
+		}
+		// Skip HEAD references like "origin/HEAD"
+		if strings.HasSuffix(b.Name, "/HEAD") {
+			continue
+		}
+		b.Remote, _ = SplitRemote(b.Name, remotes)
+		branches = append(branches, b)
+	}
+	return branches, nil
+}
+
+// ListTags lists the tags, sorted like ListLocal. Their date is that of the
+// tag itself for annotated tags.
+func ListTags(ctx context.Context, git Git, sortKey string) ([]Branch, error) {
+	return list(ctx, git, tagFormat, sortKey, "refs/tags")
+}
+
+func list(ctx context.Context, git Git, format, sortKey, prefix string) ([]Branch, error) {
+	args := []string{"for-each-ref", format}
+	if sortKey != "" {
+		args = append(args, "--sort="+sortKey)
+	}
+	args = append(args, prefix)
+	output, err := git(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	for _, record := range splitRecords(output) {
+		branches = append(branches, parseBranch(record))
+	}
+
+	// git already ordered the refs when a sort key was given
+	if sortKey == "" {
+		slices.SortFunc(branches, func(a, b Branch) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	return branches, nil
+}
+
+// SplitRemote splits a remote branch into the remote it belongs to and the
+// branch name on that remote. Remote names may contain slashes too, so the
+// longest known remote wins: myremote/release/1.0 -> myremote, release/1.0
+func SplitRemote(name string, remotes []string) (string, string) {
+	remote := ""
+	for _, r := range remotes {
+		if strings.HasPrefix(name, r+"/") && len(r) > len(remote) {
+			remote = r
+		}
+	}
+	if remote == "" {
+		// Not a known remote; assume it ends at the first slash
+		remote, _, _ = strings.Cut(name, "/")
+	}
+	return remote, strings.TrimPrefix(name, remote+"/")
+}
+
+// Pick asks the user to select one of branches under title, labelled with
+// their name and the date of their last commit. It returns
+// huh.ErrUserAborted when the user cancels.
+func Pick(title string, branches []Branch) (Branch, error) {
+	if len(branches) == 0 {
+		return Branch{}, ErrNoBranches
+	}
+
+	var options []huh.Option[int]
+	for i, b := range branches {
+		label := b.Name
+		if b.Date != "" {
+			label += "  (" + b.Date + ")"
+		}
+		options = append(options, huh.NewOption(label, i))
+	}
+
+	var selected int
+	err := huh.NewSelect[int]().
+		Title(title).
+		Options(options...).
+		Filtering(true).
+		Value(&selected).
+		Run()
+	if err != nil {
+		return Branch{}, err
+	}
+	return branches[selected], nil
+}
+
+// splitRecords splits the output of for-each-ref with a format ending in %00
+// into one record per ref. NULs, unlike newlines, can't be part of a name
+// or subject.
+func splitRecords(output []byte) []string {
+	var records []string
+	for _, record := range strings.Split(string(output), "\x00") {
+		// for-each-ref ends every record with a newline of its own
+		if record = strings.TrimPrefix(record, "\n"); record != "" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// parseBranch splits a record produced by branchFormat into its fields.
+func parseBranch(record string) Branch {
+	fields := strings.SplitN(record, "\t", 5)
+	b := Branch{Name: fields[0]}
+	if len(fields) > 1 {
+		if unix, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			b.Committed = time.Unix(unix, 0)
+		}
+	}
+	if len(fields) > 2 {
+		b.Date = fields[2]
+	}
+	if len(fields) > 3 {
+		b.Ahead, b.Behind = parseTrack(fields[3])
+	}
+	if len(fields) > 4 {
+		b.Subject = fields[4]
+	}
+	return b
+}
+
+// parseTrack reads the ahead and behind counts out of %(upstream:track),
+// e.g. "ahead 2, behind 5".
+func parseTrack(track string) (ahead, behind int) {
+	for _, part := range strings.Split(track, ", ") {
+		if n, ok := strings.CutPrefix(part, "ahead "); ok {
+			ahead, _ = strconv.Atoi(n)
+		}
+		if n, ok := strings.CutPrefix(part, "behind "); ok {
+			behind, _ = strconv.Atoi(n)
+		}
+	}
+	return ahead, behind
+}
@@ -0,0 +1,64 @@
+package branches
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitRemote(t *testing.T) {
+	remotes := []string{"origin", "upstream", "myremote", "team/fork"}
+
+	tests := []struct {
+		name       string
+		wantRemote string
+		wantBranch string
+	}{
+		{"origin/main", "origin", "main"},
+		{"origin/feature/auth", "origin", "feature/auth"},
+		{"myremote/release/1.0", "myremote", "release/1.0"},
+		{"upstream/a/b/c/d", "upstream", "a/b/c/d"},
+		{"team/fork/feature/x", "team/fork", "feature/x"},
+		{"team/other", "team", "other"},
+		{"unknown/feature/x", "unknown", "feature/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, branch := SplitRemote(tt.name, remotes)
+			if remote != tt.wantRemote || branch != tt.wantBranch {
+				t.Errorf("SplitRemote(%q) = %q, %q; want %q, %q", tt.name, remote, branch, tt.wantRemote, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestListRemote(t *testing.T) {
+	git := func(ctx context.Context, args ...string) ([]byte, error) {
+		switch args[0] {
+		case "remote":
+			return []byte("origin\nteam/fork\n"), nil
+		case "for-each-ref":
+			return []byte("team/fork/fix/x\t\t\t\t\x00\norigin/HEAD\t\t\t\t\x00\norigin\t\t\t\t\x00\n" +
+				"origin/main\t\t1 hour ago\tbehind 3\tFix login\x00\n"), nil
+		}
+		t.Fatalf("unexpected git %s", strings.Join(args, " "))
+		return nil, nil
+	}
+
+	got, err := ListRemote(context.Background(), git, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Branch{
+		{Name: "origin/main", Remote: "origin", Date: "1 hour ago", Subject: "Fix login", Behind: 3},
+		{Name: "team/fork/fix/x", Remote: "team/fork"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListRemote() = %+v; want %+v", got, want)
+	}
+	if name := got[1].LocalName(); name != "fix/x" {
+		t.Errorf("LocalName() = %q; want %q", name, "fix/x")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/charmbracelet/huh"
+)
+
+// pullRequest is an open pull request as listed by gh pr list.
+type pullRequest struct {
+	Number      int    `json:"number"`
+	HeadRefName string `json:"headRefName"`
+	Title       string `json:"title"`
+}
+
+func getPullRequests(ctx context.Context) ([]pullRequest, error) {
+	cmd := &command{exec.CommandContext(ctx, "gh", "pr", "list", "--json", "number,headRefName,title")}
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Stderr.Write(exitErr.Stderr)
+		}
+		return nil, err
+	}
+
+	var prs []pullRequest
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return nil, err
+	}
+	return prs, nil
+}
+
+// annotatePullRequests sets the pr of branches that are the head of an open
+// pull request, for --pr-status. Like the other markers it is best effort:
+// without gh, a login or a network the branches are left as they are.
+func annotatePullRequests(ctx context.Context, branches []branch) {
+	cmd := &command{exec.CommandContext(ctx, "gh", "pr", "list", "--json", "number,headRefName", "--limit", "200")}
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	var prs []pullRequest
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return
+	}
+
+	numbers := map[string]int{}
+	for _, pr := range prs {
+		// gh lists the most recent first; keep that one for reused names
+		if _, ok := numbers[pr.HeadRefName]; !ok {
+			numbers[pr.HeadRefName] = pr.Number
+		}
+	}
+	for i := range branches {
+		branches[i].pr = numbers[branches[i].name]
+	}
+}
+
+func fetchPullRequests(ctx context.Context, opts options) ([]pullRequest, error) {
+	var prs []pullRequest
+	var fetchErr error
+
+	withSpinner(opts, "Fetching pull requests...", func() {
+		prs, fetchErr = getPullRequests(ctx)
+	})
+
+	return prs, checkTimeout(ctx, fetchErr, "fetching pull requests")
+}
+
+func interactiveSwitchPR(ctx context.Context, opts options) {
+	prs, err := fetchPullRequests(ctx, opts)
+
+	if err != nil {
+		exitWithStatus(err)
+	}
+
+	if len(prs) == 0 {
+		exitNoBranches(opts, text.NoPullRequests)
+	}
+
+	var options []huh.Option[string]
+	for _, pr := range prs {
+		label := fmt.Sprintf("#%d %s   %s", pr.Number, pr.Title, grayStyle.Render(pr.HeadRefName))
+		options = append(options, huh.NewOption(label, strconv.Itoa(pr.Number)))
+	}
+
+	var selected string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(text.SelectPullRequest).
+				Options(options...).
+				Height(listHeight(len(options), formChrome)).
+				Filtering(true).
+				Value(&selected),
+		),
+	)
+
+	err = form.Run()
+	if err != nil {
+		exitCancelled(opts)
+	}
+
+	if err := checkoutPR(selected, opts); err != nil {
+		exitWithStatus(err)
+	}
+}
+
+// checkoutPR switches to the head branch of a pull request, letting gh
+// create it (and any fork remote) when needed.
+func checkoutPR(number string, opts options) error {
+	confirmDirty(opts)
+	previous, _ := getCurrentBranch()
+
+	if err := runCommand(opts, "gh", "pr", "checkout", number); err != nil {
+		return err
+	}
+
+	afterSwitch(previous, opts)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// reflogBranches returns the branches HEAD was moved to or from by checkouts
+// and switches, most recent first and each only once. Unlike the history
+// file, the reflog also knows about switches made with plain git.
+func reflogBranches(ctx context.Context) ([]string, error) {
+	// Without a commit there is no HEAD to show the reflog of
+	if unbornHead() {
+		return nil, nil
+	}
+	output, err := gitOutput(ctx, "reflog", "show", "--grep-reflog=checkout: moving from", "--format=%gs", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		// checkout: moving from <previous> to <next>
+		moves, ok := strings.CutPrefix(line, "checkout: moving from ")
+		if !ok {
+			continue
+		}
+		from, to, ok := strings.Cut(moves, " to ")
+		if !ok {
+			continue
+		}
+		for _, name := range []string{to, from} {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// fetchReflogBranches lists the local branches found in the reflog in the
+// order they were last visited. Commits HEAD was detached at and branches
+// deleted since are left out.
+func fetchReflogBranches(ctx context.Context, opts options) ([]branch, error) {
+	names, err := reflogBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := fetchLocalBranches(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var visited []branch
+	for _, name := range names {
+		if idx := slices.IndexFunc(branches, hasName(name)); idx != -1 {
+			visited = append(visited, branches[idx])
+		}
+	}
+	return visited, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// rememberedFlags are the flags remembered per repository, mapped to whether
+// they take a value. They only shape the picker; flags that act, such as
+// --delete or --print, or that name a branch are never remembered.
+var rememberedFlags = map[string]bool{
+	"-a": false, "--all": false,
+	"-r": false, "--remote": false,
+	"-t": false, "--tags": false,
+	"-R": false, "--recent": false,
+	"-f": false, "--fetch": false,
+	"-p": true, "--pattern": true,
+	"--reflog":              false,
+	"--sort":                true,
+	"--exclude":             true,
+	"--tracked":             false,
+	"--untracked":           false,
+	"--mine":                false,
+	"--author":              true,
+	"--scope":               false,
+	"--two-step":            false,
+	"--hide-current-remote": false,
+	"--detach-remote":       false,
+	"--no-remote-strip":     false,
+	"--pr-status":           false,
+	"--limit":               true,
+	"--format":              true,
+	"--timeout":             true,
+	"--no-current":          false,
+	"--no-pin-current":      false,
+	"--no-preview":          false,
+	"--no-auto":             false,
+}
+
+// rememberedEntry is the flags last used in a repository.
+type rememberedEntry struct {
+	Repo  string   `json:"repo"`
+	Flags []string `json:"flags"`
+}
+
+// loadRemembered reads the remembered flags file. A missing or unreadable
+// file yields no entries.
+func loadRemembered() []rememberedEntry {
+	var entries []rememberedEntry
+	if !loadJSON("flags.json", &entries) {
+		return nil
+	}
+	return entries
+}
+
+func saveRemembered(entries []rememberedEntry) error {
+	return saveJSON("flags.json", entries)
+}
+
+// rememberable reports whether args consist of remembered flags only.
+func rememberable(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "--scope=") {
+			continue
+		}
+		value, ok := rememberedFlags[args[i]]
+		if !ok {
+			return false
+		}
+		if value {
+			i++
+		}
+	}
+	return true
+}
+
+// recallFlags remembers args as the flags of the current repository when
+// they are all picker flags, and without any args returns the options of
+// the flags remembered last, saying which. --no-remember does neither.
+// Remembering is best effort, like the history.
+func recallFlags(args []string, opts options) options {
+	if opts.noRemember {
+		return opts
+	}
+	repo, err := getRepoRoot()
+	if err != nil {
+		// Not in a repository, which main reports
+		return opts
+	}
+	entries := loadRemembered()
+	i := slices.IndexFunc(entries, func(e rememberedEntry) bool { return e.Repo == repo })
+
+	if len(args) == 0 {
+		if i == -1 {
+			return opts
+		}
+		recalled, err := parseArgs(entries[i].Flags)
+		if err != nil {
+			return opts
+		}
+		notice(recalled, fmt.Sprintf("(using remembered flags: %s)", strings.Join(entries[i].Flags, " ")))
+		return recalled
+	}
+
+	if rememberable(args) {
+		if i == -1 {
+			entries = append(entries, rememberedEntry{Repo: repo})
+			i = len(entries) - 1
+		}
+		entries[i].Flags = args
+		_ = saveRemembered(entries)
+	}
+	return opts
+}
+
+// forgetFlags drops the remembered flags of the current repository.
+func forgetFlags(opts options) error {
+	repo, err := getRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	entries := loadRemembered()
+	kept := slices.DeleteFunc(slices.Clone(entries), func(e rememberedEntry) bool {
+		return e.Repo == repo
+	})
+	if len(kept) < len(entries) {
+		if err := saveRemembered(kept); err != nil {
+			return err
+		}
+	}
+	notice(opts, "Forgot the remembered flags of this repository.")
+	return nil
+}
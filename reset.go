@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// resetTo hard-resets the current branch to ref for --reset-to, fetching
+// first with --fetch and switching to branch if one is given. The commits
+// only the branch has are listed first, and throwing them or uncommitted
+// changes away takes a yes, or --yes without a terminal to ask on.
+func resetTo(ctx context.Context, ref, branch string, opts options) error {
+	if opts.fetch {
+		if err := updateRemotes(ctx, opts); err != nil {
+			return err
+		}
+	}
+	if !isCommit(ref) {
+		return fmt.Errorf("invalid ref: '%s' is not a commit", ref)
+	}
+	if branch != "" {
+		if err := switchNamedBranch(ctx, branch, opts); err != nil {
+			return err
+		}
+	}
+	current, err := getCurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	output, err := gitCommand("log", "--oneline", "--no-decorate", ref+"..HEAD").Output()
+	if err != nil {
+		return err
+	}
+	lost := strings.TrimSpace(string(output))
+	dirty := isDirty()
+
+	if lost != "" || dirty {
+		if lost != "" {
+			fmt.Fprintln(os.Stderr, grayStyle.Render(fmt.Sprintf("Commits on '%s' that are not on '%s':", current, ref)))
+			fmt.Fprintln(os.Stderr, lost)
+		}
+		if dirty {
+			fmt.Fprintln(os.Stderr, grayStyle.Render("Uncommitted changes to tracked files will be lost too."))
+		}
+		if !opts.dryRun {
+			if !opts.yes && !stdinIsTerminal() {
+				return fmt.Errorf("not resetting '%s' without --yes", current)
+			}
+			if !confirm(fmt.Sprintf(text.ConfirmReset, current, ref), false, opts.yes) {
+				exitCancelled(opts)
+			}
+		}
+	}
+
+	if err := runGit(opts, "reset", "--hard", "--quiet", ref); err != nil {
+		return err
+	}
+	invalidateCache()
+	if lost != "" {
+		notice(opts, "Run `git reset --hard ORIG_HEAD` to get the commits back.")
+	}
+	return nil
+}
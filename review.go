@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	addedStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	removedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	addedPattern   = regexp.MustCompile(`\++`)
+	removedPattern = regexp.MustCompile(`-+`)
+)
+
+// reviewChanges shows the uncommitted changes before a switch with --review
+// and asks whether to take them along, stash them away or stay. It does
+// nothing on a clean tree or without a terminal to ask on.
+func reviewChanges(opts options) options {
+	if !opts.review || opts.stash || opts.forceSwitch || !stdinIsTerminal() || !isDirty() {
+		return opts
+	}
+
+	fmt.Fprintln(os.Stderr, diffStat())
+
+	var choice string
+	err := huh.NewSelect[string]().
+		Title(text.ReviewChanges).
+		Options(
+			huh.NewOption(text.ReviewKeep, "keep"),
+			huh.NewOption(text.ReviewStash, "stash"),
+			huh.NewOption(text.ReviewCancel, "cancel"),
+		).
+		Value(&choice).
+		Run()
+	if err != nil || choice == "cancel" {
+		exitCancelled(opts)
+	}
+
+	if choice == "stash" {
+		if err := runGit(opts, "stash", "push"); err != nil {
+			exitWithStatus(err)
+		}
+		notice(opts, text.Stashed)
+	}
+	// The user just decided; --confirm-dirty would ask the same again
+	opts.confirmDirty = false
+	return opts
+}
+
+// diffStat summarizes the uncommitted changes like git diff --stat, with
+// the added and removed bars in color, followed by the number of untracked
+// files.
+func diffStat() string {
+	var lines []string
+	// Before the first commit there is no HEAD to diff against
+	output, err := gitCommand("diff", "--stat", "--color=never", "HEAD").Output()
+	if stat := strings.TrimRight(string(output), "\n"); err == nil && stat != "" {
+		for _, line := range strings.Split(stat, "\n") {
+			if name, graph, ok := strings.Cut(line, "|"); ok {
+				graph = addedPattern.ReplaceAllStringFunc(graph, func(bar string) string {
+					return addedStyle.Render(bar)
+				})
+				graph = removedPattern.ReplaceAllStringFunc(graph, func(bar string) string {
+					return removedStyle.Render(bar)
+				})
+				line = name + "|" + graph
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if output, err := gitCommand("status", "--porcelain").Output(); err == nil {
+		untracked := strings.Count("\n"+string(output), "\n??")
+		switch {
+		case untracked == 1:
+			lines = append(lines, grayStyle.Render(" and 1 untracked file"))
+		case untracked > 1:
+			lines = append(lines, grayStyle.Render(fmt.Sprintf(" and %d untracked files", untracked)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// scopeHere is the --scope value standing for the top-level directory of the
+// repository that the working directory is in.
+const scopeHere = "."
+
+// resolveScope turns the scope of --scope or sw.scope into a branch name
+// prefix ending in a slash, or "" for no scope. "." is the first directory
+// of the path from the repository root to the working directory, e.g.
+// "team-a/" in team-a/src; there is no such scope at the root.
+func resolveScope(scope string) (string, error) {
+	if scope == scopeHere {
+		output, err := gitCommand("rev-parse", "--show-prefix").Output()
+		if err != nil {
+			// Not in a repository, which main reports
+			return "", nil
+		}
+		scope, _, _ = strings.Cut(strings.TrimSpace(string(output)), "/")
+	}
+	scope = strings.Trim(scope, "/")
+	if scope == "" {
+		return "", nil
+	}
+	if gitCommand("check-ref-format", "--branch", scope).Run() != nil {
+		return "", fmt.Errorf("invalid scope: '%s' is not a valid branch name prefix", scope)
+	}
+	return scope + "/", nil
+}
+
+// inScope reports whether b, or b without its remote, starts with scope.
+func inScope(scope string, b branch) bool {
+	return strings.HasPrefix(b.name, scope) || strings.HasPrefix(b.localName(), scope)
+}
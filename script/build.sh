@@ -0,0 +1,31 @@
+#!/usr/bin/env bash
+# Builds the release binaries for cli/gh-extension-precompile, which passes
+# the tag being released as the first argument.
+set -euo pipefail
+
+tag="${1:?usage: script/build.sh TAG}"
+platforms=(
+  darwin-amd64
+  darwin-arm64
+  freebsd-amd64
+  linux-386
+  linux-amd64
+  linux-arm
+  linux-arm64
+  windows-386
+  windows-amd64
+  windows-arm64
+)
+
+mkdir -p dist
+for platform in "${platforms[@]}"; do
+  goos="${platform%-*}"
+  goarch="${platform#*-}"
+  ext=""
+  if [ "$goos" = windows ]; then
+    ext=".exe"
+  fi
+  GOOS="$goos" GOARCH="$goarch" CGO_ENABLED=0 go build -trimpath \
+    -ldflags "-s -w -X main.version=${tag}" \
+    -o "dist/${platform}${ext}" .
+done
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// configPath returns the path of the named file in the gh-sw directory under
+// the user config directory.
+func configPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-sw", name), nil
+}
+
+// loadJSON reads the named file in the gh-sw config directory into v and
+// reports whether it could. A missing or unreadable file is no error, as
+// gh-sw only keeps state there that it can do without.
+func loadJSON(name string, v any) bool {
+	path, err := configPath(name)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// saveJSON writes v to the named file in the gh-sw config directory,
+// creating the directory if need be.
+func saveJSON(name string, v any) error {
+	path, err := configPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// storedBranches returns the branches that entries of repo name, in the
+// order of entries and leaving out current. key gives the repository and
+// branch of an entry. Entries of branches that no longer exist are deleted
+// from entries, and pruned reports whether there were any.
+func storedBranches[E any](entries *[]E, key func(E) (string, string), repo string, branches []branch, current string) (stored []branch, pruned bool) {
+	*entries = slices.DeleteFunc(*entries, func(e E) bool {
+		entryRepo, name := key(e)
+		if entryRepo != repo {
+			return false
+		}
+		idx := slices.IndexFunc(branches, hasName(name))
+		if idx == -1 {
+			// The branch may only be filtered out of the list
+			if !localBranchExists(name) {
+				pruned = true
+				return true
+			}
+			return false
+		}
+		if name != current {
+			stored = append(stored, branches[idx])
+		}
+		return false
+	})
+	return stored, pruned
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// updateSubmodules checks out the submodule commits recorded on the branch
+// just switched to, initializing new submodules on the way. Repositories
+// without a .gitmodules file are left alone. It is given no time limit, as
+// cloning submodules can take a while, but ctrl+c still cancels it.
+func updateSubmodules(ctx context.Context, opts options) error {
+	root, err := getRepoRoot()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(root, ".gitmodules")); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	var output []byte
+	withSpinner(opts, "Updating submodules...", func() {
+		cmd := gitCommandContext(ctx, "submodule", "update", "--init", "--recursive")
+		output, err = cmd.CombinedOutput()
+	})
+	if err != nil {
+		// The switch itself went through; only the submodules lag behind
+		os.Stderr.Write(output)
+		return fmt.Errorf("switched branches, but updating submodules failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// listTimeout is the limit resolved from --timeout. It is set in main so
+// that a timeout can say how long it waited.
+var listTimeout time.Duration
+
+// timeoutError is a listing cut short by --timeout.
+type timeoutError struct {
+	after time.Duration
+	what  string
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("Timed out after %s %s (increase with --timeout).", e.after, e.what)
+}
+
+// checkTimeout replaces err with a *timeoutError when ctx ran out of time,
+// as git killed by the deadline only reports the signal.
+func checkTimeout(ctx context.Context, err error, what string) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &timeoutError{after: listTimeout, what: what}
+	}
+	return err
+}
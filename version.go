@@ -0,0 +1,19 @@
+package main
+
+import "runtime/debug"
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3"; see
+// script/build.sh.
+var version = "dev"
+
+// buildVersion returns the version gh-sw was built as. Without ldflags, it
+// falls back to the module version recorded by go install.
+func buildVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
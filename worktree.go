@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// otherWorktrees maps each branch checked out in another worktree to that
+// worktree's path, relative to the current one where possible. git refuses
+// to switch to these branches.
+func otherWorktrees() map[string]string {
+	root, err := getRepoRoot()
+	if err != nil {
+		return nil
+	}
+	output, err := gitCommand("worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return nil
+	}
+
+	worktrees := map[string]string{}
+	var path string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			path = strings.TrimPrefix(line, "worktree ")
+		case strings.HasPrefix(line, "branch refs/heads/"):
+			if path == root {
+				continue
+			}
+			if rel, err := filepath.Rel(root, path); err == nil {
+				path = rel
+			}
+			worktrees[strings.TrimPrefix(line, "branch refs/heads/")] = path
+		}
+	}
+	return worktrees
+}
+
+// annotateWorktrees sets the worktree of branches checked out elsewhere.
+func annotateWorktrees(branches []branch) {
+	worktrees := otherWorktrees()
+	if len(worktrees) == 0 {
+		return
+	}
+	for i := range branches {
+		branches[i].worktree = worktrees[branches[i].name]
+	}
+}
+
+// worktreePath is where a new worktree for branch goes: next to the
+// repository, named after the branch with its slashes turned into dashes.
+func worktreePath(branch string) (string, error) {
+	root, err := getRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(root), strings.ReplaceAll(branch, "/", "-")), nil
+}
+
+// addWorktree checks b out in a new worktree and prints the worktree's
+// path. A remote branch gets a local branch tracking it unless it has one.
+func addWorktree(b branch, opts options) error {
+	name := b.localName()
+	if path, ok := otherWorktrees()[name]; ok {
+		return fmt.Errorf("branch '%s' is already checked out in %s", name, path)
+	}
+	path, err := worktreePath(name)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"worktree", "add", "--quiet"}
+	switch {
+	case b.remote == "" || localBranchExists(name):
+		args = append(args, path, name)
+	case opts.noTrack:
+		args = append(args, "--no-track", "-b", name, path, b.name)
+	default:
+		args = append(args, "--track", "-b", name, path, b.name)
+	}
+	if err := runGit(opts, args...); err != nil {
+		return err
+	}
+	if !opts.dryRun {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// addNamedWorktree adds a worktree for the branch called name, which may be
+// a remote branch such as origin/feature/x. A branch that doesn't exist is
+// created in the new worktree, after asking.
+func addNamedWorktree(ctx context.Context, name string, opts options) error {
+	if localBranchExists(name) {
+		return addWorktree(branch{name: name}, opts)
+	}
+	if b, ok := remoteBranch(ctx, name); ok {
+		return addWorktree(b, opts)
+	}
+	if refs, err := remoteBranchesNamed(name); err == nil && len(refs) == 1 {
+		if b, ok := remoteBranch(ctx, refs[0]); ok {
+			return addWorktree(b, opts)
+		}
+	}
+
+	if err := validBranchName(name); err != nil {
+		return err
+	}
+	if err := validStartPoint(opts.from); err != nil {
+		return err
+	}
+	if stdinIsTerminal() && !confirm(fmt.Sprintf(text.ConfirmWorktree, name), false, opts.yes) {
+		exitCancelled(opts)
+	}
+
+	path, err := worktreePath(name)
+	if err != nil {
+		return err
+	}
+	args := []string{"worktree", "add", "--quiet", "-b", name, path}
+	if opts.from != "" {
+		args = append(args, opts.from)
+	}
+	if err := runGit(opts, args...); err != nil {
+		return err
+	}
+	if !opts.dryRun {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// interactiveWorktree lets the user pick any number of branches, remote
+// ones with -r or -a, and adds a worktree for each. Branches that are
+// already checked out somewhere are left out.
+func interactiveWorktree(ctx context.Context, opts options) {
+	s := scopeLocal
+	if opts.all {
+		s = scopeAll
+	} else if opts.remote {
+		s = scopeRemote
+	}
+	refs, err := fetchScope(ctx, s, opts)
+	if err != nil {
+		exitWithStatus(err)
+	}
+
+	current, _ := getCurrentBranch()
+	var branches []branch
+	for _, b := range refs.local {
+		if b.name != current && b.worktree == "" {
+			branches = append(branches, b)
+		}
+	}
+	branches = append(branches, refs.remote...)
+
+	if len(branches) == 0 {
+		exitNoBranches(opts, noBranchesMessage(opts, text.NoWorktreeBranches))
+	}
+
+	if err := formatLabels(ctx, opts, branches); err != nil {
+		exitWithStatus(err)
+	}
+
+	byName := map[string]branch{}
+	var options []huh.Option[string]
+	cols := branchColumns(branches, nil)
+	for _, b := range branches {
+		byName[b.name] = b
+		options = append(options, branchOption(b, cols))
+	}
+
+	var selected []string
+	err = huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(text.SelectWorktrees).
+				Options(options...).
+				Height(listHeight(len(options), formChrome)).
+				Value(&selected),
+		),
+	).Run()
+	if err != nil || len(selected) == 0 {
+		exitCancelled(opts)
+	}
+
+	for _, name := range selected {
+		if err := addWorktree(byName[name], opts); err != nil {
+			exitWithStatus(err)
+		}
+	}
+}